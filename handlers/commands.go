@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+
+	"github.com/skybet/go-helpdesk/lifecycle"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+)
+
+// maxListedTickets limits the number of tickets shown by TicketList
+const maxListedTickets = 20
+
+// RegisterTicketCommands adds the ticket subcommands to a slash command router
+func RegisterTicketCommands(cr *server.CommandRouter) error {
+	if _, err := cr.Handle("status", "Show the status of a ticket", TicketStatus,
+		server.Arg("ticket", server.StringArg), server.Example("status HD-1")); err != nil {
+		return err
+	}
+	if _, err := cr.Handle("assign", "Assign a ticket to someone", TicketAssign,
+		server.Arg("assignee", server.UserArg), server.Arg("ticket", server.StringArg),
+		server.Flag("note", "is posted in the ticket thread"), server.Example("assign @bob HD-1 --note \"back tomorrow\"")); err != nil {
+		return err
+	}
+	_, err := cr.Handle("list", "List open tickets, those assigned to you (mine) or raised by you (requested)", TicketList,
+		server.OptionalArg("filter", server.StringArg), server.BoolFlag("all", "includes resolved and closed tickets"), server.Example("list mine --all"))
+	return err
+}
+
+// TicketStatus is a subcommand handler which shows a ticket to the user
+func TicketStatus(res *server.Response, req *server.Request, sc slack.SlashCommand, args *server.Args) error {
+	id := args.String("ticket")
+	t, err := ticketStore.Get(id)
+	if err == store.ErrNotFound {
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("Ticket *%s* does not exist", id)})
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to get ticket %s: %s", id, err)
+	}
+	return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("%s\n>%s", ticketSummary(t), t.Description)})
+}
+
+// TicketAssign is a subcommand handler which assigns a ticket to a user
+func TicketAssign(res *server.Response, req *server.Request, sc slack.SlashCommand, args *server.Args) error {
+	id := args.String("ticket")
+	assignee := args.User("assignee")
+	t, err := lifecycle.Apply(ticketStore, id, sc.UserID, lifecycle.Assign(assignee.ID))
+	if err == store.ErrNotFound {
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("Ticket *%s* does not exist", id)})
+	}
+	if te, ok := err.(*lifecycle.TransitionError); ok {
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("%s can not be assigned as it is %s", id, strings.ToLower(lifecycle.Label(te.From)))})
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to update ticket %s: %s", id, err)
+	}
+	log.Printf("User: '%s' assigned ticket: '%s' to: '%s'", sc.UserName, t.ID, assignee.ID)
+
+	if ref, ok := ticketRef(t); ok {
+		update := fmt.Sprintf("<@%s> assigned *%s* to <@%s>", sc.UserID, t.ID, assignee.ID)
+		if note := args.Flag("note"); note != "" {
+			update += "\n>" + note
+		}
+		if err := refreshTicket(ref, t, update); err != nil {
+			return err
+		}
+	}
+	return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("*%s* is now assigned to <@%s>", t.ID, assignee.ID)})
+}
+
+// TicketList is a subcommand handler which lists open tickets
+func TicketList(res *server.Response, req *server.Request, sc slack.SlashCommand, args *server.Args) error {
+	var f store.Filter
+	switch filter := strings.ToLower(args.String("filter")); filter {
+	case "":
+	case "mine":
+		f.Assignee = sc.UserID
+	case "requested":
+		f.Requester = sc.UserID
+	default:
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("Unknown filter `%s`, use `mine` or `requested`", filter)})
+	}
+	tickets, err := ticketStore.List(f)
+	if err != nil {
+		return fmt.Errorf("Failed to list tickets: %s", err)
+	}
+
+	var lines []string
+	for _, t := range tickets {
+		if !args.Bool("all") && !lifecycle.IsOpen(t.Status) {
+			continue
+		}
+		if len(lines) == maxListedTickets {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s - %s", ticketSummary(t), summarise(t.Description)))
+	}
+	if len(lines) == 0 {
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: "No tickets found"})
+	}
+	return server.ReplyEphemeral(res, req, &slack.Msg{Text: strings.Join(lines, "\n")})
+}
+
+// ticketSummary describes the state of a ticket in a single line
+func ticketSummary(t *store.Ticket) string {
+	s := fmt.Sprintf("*%s* %s, raised by <@%s>", t.ID, lifecycle.Label(t.Status), t.Requester)
+	if t.Assignee != "" {
+		s += fmt.Sprintf(" and assigned to <@%s>", t.Assignee)
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skybet/go-helpdesk/lifecycle"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+// runTicketCommand executes a /help-me subcommand and returns the message sent back to the user
+func runTicketCommand(t *testing.T, text string) string {
+	cr := &server.CommandRouter{Command: "/help-me"}
+	if err := RegisterTicketCommands(cr); err != nil {
+		t.Fatalf("Unexpected error registering the ticket commands: %s", err)
+	}
+	words := strings.SplitN(text, " ", 2)
+	sub := cr.Subcommand(words[0])
+	args, err := sub.Parse(strings.Join(words[1:], " "))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %s: %s", text, err)
+	}
+
+	w := httptest.NewRecorder()
+	req := &server.Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	sc := slack.SlashCommand{Command: "/help-me", UserID: "W0AGENT", UserName: "agent"}
+	if err := sub.Handler(&server.Response{ResponseWriter: w}, req, sc, args); err != nil {
+		t.Fatalf("Unexpected error from %s: %s", text, err)
+	}
+	var msg slack.Msg
+	if err := json.NewDecoder(w.Body).Decode(&msg); err != nil {
+		t.Fatalf("Expected a JSON response: %s", err)
+	}
+	if msg.ResponseType != "ephemeral" {
+		t.Fatalf("Expected the response to only be visible to the user")
+	}
+	return msg.Text
+}
+
+func TestTicketStatus(t *testing.T) {
+	newTicketMocks(t)
+	if got := runTicketCommand(t, "status hd-1"); got != "*HD-1* New, raised by <@W12A3BCDEF>\n>My VPN is down" {
+		t.Fatalf("Unexpected status: %s", got)
+	}
+	if got := runTicketCommand(t, "status HD-42"); got != "Ticket *HD-42* does not exist" {
+		t.Fatalf("Unexpected status: %s", got)
+	}
+}
+
+func TestTicketAssign(t *testing.T) {
+	mockSlack, ts := newTicketMocks(t)
+	tk, _ := ts.Get("HD-1")
+	tk.Metadata = map[string]string{MetadataSlackChannel: ticketRefFixture.ChannelID, MetadataSlackTs: ticketRefFixture.Timestamp}
+	ts.Update(tk)
+	mockSlack.On("UpdateMessage", ticketRefFixture, mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostThreadReply", ticketRefFixture, wrapper.Message{Text: "<@W0AGENT> assigned *HD-1* to <@U123>\n>back tomorrow"}).Return(wrapper.MessageRef{}, nil)
+
+	got := runTicketCommand(t, "assign <@U123|bob> HD-1 --note \"back tomorrow\"")
+	if got != "*HD-1* is now assigned to <@U123>" {
+		t.Fatalf("Unexpected response: %s", got)
+	}
+	mockSlack.AssertExpectations(t)
+	if tk, _ := ts.Get("HD-1"); tk.Assignee != "U123" || tk.Status != lifecycle.InProgress {
+		t.Fatalf("Unexpected ticket: %+v", tk)
+	}
+
+	tk, _ = ts.Get("HD-1")
+	lifecycle.Resolve(tk, "W0AGENT")
+	ts.Update(tk)
+	if got := runTicketCommand(t, "assign <@U123> HD-1"); got != "HD-1 can not be assigned as it is resolved" {
+		t.Fatalf("Unexpected response: %s", got)
+	}
+}
+
+func TestTicketList(t *testing.T) {
+	_, ts := newTicketMocks(t)
+	ts.Create(&store.Ticket{Status: lifecycle.InProgress, Requester: "W0AGENT", Assignee: "W0AGENT", Description: "Printer on fire"})
+	ts.Create(&store.Ticket{Status: lifecycle.Resolved, Requester: "W0AGENT", Description: "Need a new mouse"})
+
+	tt := []struct {
+		text, want string
+	}{
+		{"list", "*HD-1* New, raised by <@W12A3BCDEF> - My VPN is down\n*HD-2* In progress, raised by <@W0AGENT> and assigned to <@W0AGENT> - Printer on fire"},
+		{"list mine", "*HD-2* In progress, raised by <@W0AGENT> and assigned to <@W0AGENT> - Printer on fire"},
+		{"list requested --all", "*HD-2* In progress, raised by <@W0AGENT> and assigned to <@W0AGENT> - Printer on fire\n*HD-3* Resolved, raised by <@W0AGENT> - Need a new mouse"},
+		{"list nobody", "Unknown filter `nobody`, use `mine` or `requested`"},
+	}
+	for _, tc := range tt {
+		if got := runTicketCommand(t, tc.text); got != tc.want {
+			t.Fatalf("Unexpected response to %s:\n%s", tc.text, got)
+		}
+	}
+}
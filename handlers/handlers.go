@@ -3,8 +3,8 @@ package handlers
 import (
 	"fmt"
 
-	"github.com/nlopes/slack"
 	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
 
 	"github.com/skybet/go-helpdesk/server"
 	"github.com/skybet/go-helpdesk/wrapper"
@@ -17,45 +17,142 @@ func Init(sw wrapper.SlackWrapper) {
 	slackWrapper = sw
 }
 
-// HelpCallback is a handler that takes a dialogCallback, generated by the HelpRequest
-// handler and logs the help request
-func HelpCallback(res *server.Response, req *server.Request, ctx interface{}) error {
-	d, ok := ctx.(*slack.DialogCallback)
-	if !ok {
-		return fmt.Errorf("Expected a *slack.DialogCallback to be passed to the handler")
+// Block IDs used by the help request modal. Each block contains a single element
+// whose action ID is the same as the block ID
+const (
+	helpDescriptionBlock = "HelpRequestDescription"
+	helpUrgencyBlock     = "HelpRequestUrgency"
+	helpAreasBlock       = "HelpRequestAreas"
+	helpNeededByBlock    = "HelpRequestNeededBy"
+)
+
+// HelpSubmission is the help request captured by the help request modal
+type HelpSubmission struct {
+	Description string
+	Urgency     string
+	Areas       []string
+	NeededBy    string
+	ChannelID   string // Channel the request was raised from
+}
+
+// helpSubmissionFromView extracts a HelpSubmission from the state of a submitted help request modal
+func helpSubmissionFromView(v slack.View) HelpSubmission {
+	hs := HelpSubmission{ChannelID: v.PrivateMetadata}
+	if v.State == nil {
+		return hs
 	}
-	log.Printf("User: '%s' Requested Help: '%s'", d.User.Name, d.Submission["HelpRequestDescription"])
-	return nil
+	values := v.State.Values
+	hs.Description = values[helpDescriptionBlock][helpDescriptionBlock].Value
+	hs.Urgency = values[helpUrgencyBlock][helpUrgencyBlock].SelectedOption.Value
+	for _, o := range values[helpAreasBlock][helpAreasBlock].SelectedOptions {
+		hs.Areas = append(hs.Areas, o.Value)
+	}
+	hs.NeededBy = values[helpNeededByBlock][helpNeededByBlock].SelectedDate
+	return hs
 }
 
-// HelpRequest is a handler that creates a dialog in Slack to capture a
-// customers help request
-func HelpRequest(res *server.Response, req *server.Request, ctx interface{}) error {
-	sc, ok := ctx.(slack.SlashCommand)
-	if !ok {
-		return fmt.Errorf("Expected a slack.SlashCommand to be passed to the handler")
+// HelpCallback is a handler that takes a view submission, generated by the HelpRequest
+// handler, records the help request and confirms receipt to the requester. A failure to
+// confirm is logged rather than returned, as the help request has already been recorded
+func HelpCallback(res *server.Response, req *server.Request, ic *slack.InteractionCallback) error {
+	hs := helpSubmissionFromView(ic.View)
+	t, err := createTicket(ic.User, hs, nil)
+	if err != nil {
+		return err
 	}
-	descriptionElement := slack.DialogInput{
-		Type:        "text",
-		Label:       "Help Request Description",
-		Placeholder: "Describe what you would like help with ...",
-		Name:        "HelpRequestDescription",
+	log.Printf("User: '%s' Requested Help: '%s' Ticket: '%s'", ic.User.Name, hs.Description, t.ID)
+
+	msg := wrapper.Message{Text: fmt.Sprintf("Thanks <@%s>, we have received your help request *%s*:\n>%s", ic.User.ID, t.ID, hs.Description)}
+	// The ticket has been saved, failing would invite the user to submit it again
+	if err := confirm(hs.ChannelID, ic.User.ID, msg); err != nil {
+		log.Errorf("Failed to confirm help request %s: %s", t.ID, err)
+	}
+	return nil
+}
+
+// HelpMention is a handler for a message addressed to the app, e.g. "@helpdesk my VPN is down"
+// The message is recorded as a help request and acknowledged in a thread under it
+func HelpMention(res *server.Response, req *server.Request, m *server.Message) error {
+	hs := HelpSubmission{Description: m.Text, ChannelID: m.Channel}
+	t, err := createTicket(slack.User{ID: m.User}, hs, nil)
+	if err != nil {
+		return err
 	}
+	log.Printf("User: '%s' Requested Help: '%s' Ticket: '%s'", m.User, hs.Description, t.ID)
 
-	elements := []slack.DialogElement{
-		descriptionElement,
+	msg := wrapper.Message{Text: fmt.Sprintf("Thanks <@%s>, we have received your help request *%s*", m.User, t.ID)}
+	if _, err := slackWrapper.PostThreadReply(wrapper.MessageRef{ChannelID: m.Channel, Timestamp: m.ThreadTS()}, msg); err != nil {
+		return fmt.Errorf("Failed to confirm help request: %s", err)
 	}
+	return nil
+}
 
-	dialog := slack.Dialog{
-		CallbackID:     "HelpRequest",
-		Title:          "Request Help",
-		SubmitLabel:    "Create",
-		NotifyOnCancel: true,
-		Elements:       elements,
+// confirm sends msg privately to userID. If the request was not raised from a
+// channel the message is sent to the user directly instead
+func confirm(channelID, userID string, msg wrapper.Message) error {
+	if channelID == "" {
+		_, err := slackWrapper.PostMessage(userID, msg)
+		return err
 	}
+	return slackWrapper.PostEphemeral(channelID, userID, msg)
+}
 
-	if err := slackWrapper.OpenDialog(sc.TriggerID, dialog); err != nil {
-		return fmt.Errorf("Failed to open dialog: %s", err)
+// HelpRequest is a handler that opens a modal in Slack to capture a
+// customers help request
+func HelpRequest(res *server.Response, req *server.Request, sc slack.SlashCommand) error {
+	if _, err := slackWrapper.OpenView(sc.TriggerID, helpRequestModal(sc.ChannelID)); err != nil {
+		return fmt.Errorf("Failed to open modal: %s", err)
 	}
 	return nil
 }
+
+// helpRequestModal builds the modal used to capture a help request
+// The originating channel is stored in the private metadata so it is available on submission
+func helpRequestModal(channelID string) slack.ModalViewRequest {
+	plainText := func(t string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.PlainTextType, t, false, false)
+	}
+	option := func(value, text string) *slack.OptionBlockObject {
+		return slack.NewOptionBlockObject(value, plainText(text), nil)
+	}
+
+	description := slack.NewPlainTextInputBlockElement(plainText("Describe what you would like help with ..."), helpDescriptionBlock)
+	description.Multiline = true
+
+	urgency := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, plainText("How urgent is this?"), helpUrgencyBlock,
+		option("low", "Low - whenever you get a chance"),
+		option("medium", "Medium - it is slowing me down"),
+		option("high", "High - I am blocked"),
+	)
+
+	areas := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic, plainText("Select everything affected"), helpAreasBlock,
+		option("access", "Access & permissions"),
+		option("hardware", "Hardware"),
+		option("network", "Network & VPN"),
+		option("software", "Software"),
+		option("other", "Other"),
+	)
+	areasBlock := slack.NewInputBlock(helpAreasBlock, plainText("Affected areas"), areas)
+	areasBlock.Optional = true
+
+	neededBy := slack.NewDatePickerBlockElement(helpNeededByBlock)
+	neededByBlock := slack.NewInputBlock(helpNeededByBlock, plainText("Needed by"), neededBy)
+	neededByBlock.Optional = true
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      "HelpRequest",
+		Title:           plainText("Request Help"),
+		Submit:          plainText("Create"),
+		Close:           plainText("Cancel"),
+		PrivateMetadata: channelID,
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				slack.NewInputBlock(helpDescriptionBlock, plainText("Help Request Description"), description),
+				slack.NewInputBlock(helpUrgencyBlock, plainText("Urgency"), urgency),
+				areasBlock,
+				neededByBlock,
+			},
+		},
+	}
+}
@@ -5,88 +5,156 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/nlopes/slack"
 	"github.com/skybet/go-helpdesk/mocks"
 	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+	"github.com/slack-go/slack"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestHelpCallback(t *testing.T) {
-	t.Skip("Test no longer relevant. Consider implementing when callback does something.")
+	submission := func(channelID string) *slack.InteractionCallback {
+		return &slack.InteractionCallback{
+			Type: slack.InteractionTypeViewSubmission,
+			User: slack.User{ID: "W12A3BCDEF", Name: "dreamweaver"},
+			View: slack.View{
+				CallbackID:      "HelpRequest",
+				PrivateMetadata: channelID,
+				State: &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+					helpDescriptionBlock: {helpDescriptionBlock: {Value: "My VPN is down"}},
+				}},
+			},
+		}
+	}
 	tt := []struct {
-		name       string
-		jsonString interface{}
-		err        error
+		name  string
+		ic    *slack.InteractionCallback
+		setup func(m *mocks.SlackWrapper)
 	}{
 		{
-			"All OK, Should Pass",
-			"{\"type\": \"dialog_submission\",\"submission\": {\"name\": \"Sigourney Dreamweaver\",\"email\": \"sigdre@example.com\",\"phone\": \"+1 800-555-1212\",\"meal\": \"burrito\",\"comment\": \"No sour cream please\",\"team_channel\": \"C0LFFBKPB\",\"who_should_sing\": \"U0MJRG1AL\"},\"callback_id\": \"employee_offsite_1138b\",\"team\": {\"id\": \"T1ABCD2E12\",\"domain\": \"coverbands\"},\"user\": {\"id\": \"W12A3BCDEF\",\"name\": \"dreamweaver\"},\"channel\": {\"id\": \"C1AB2C3DE\",\"name\": \"coverthon-1999\"},\"action_ts\": \"936893340.702759\",\"token\": \"TOKEN\",\"response_url\": \"https://hooks.slack.com/app/T012AB0A1/123456789/JpmK0yzoZDeRiqfeduTBYXWQ\"}",
-			nil,
+			"Confirms in the originating channel",
+			submission("C1AB2C3DE"),
+			func(m *mocks.SlackWrapper) {
+				m.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+				m.On("PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", wrapper.Message{Text: "Thanks <@W12A3BCDEF>, we have received your help request *HD-1*:\n>My VPN is down"}).Return(nil)
+			},
 		},
 		{
-			"Type Failure",
-			42,
-			errors.New("Expected a string to be passed to the handler"),
+			"Confirms directly without a channel",
+			submission(""),
+			func(m *mocks.SlackWrapper) {
+				m.On("PostMessage", "W12A3BCDEF", mock.Anything).Return(wrapper.MessageRef{}, nil)
+			},
 		},
 		{
-			"Invalid Json",
-			"{\"type\":",
-			errors.New("unexpected end of JSON input"),
+			"Slack Failure",
+			submission("C1AB2C3DE"),
+			func(m *mocks.SlackWrapper) {
+				m.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+				m.On("PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", mock.Anything).Return(errors.New("not_in_channel"))
+			},
 		},
 	}
 
 	for _, tc := range tt {
-		t.Run(tc.name, func(T *testing.T) {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSlack := &mocks.SlackWrapper{}
+			tc.setup(mockSlack)
+			Init(mockSlack)
+			ts := store.NewMemory("HD")
+			InitStore(ts, "")
 
 			r := httptest.NewRequest("POST", "/slack", nil)
 			w := httptest.NewRecorder()
 			req := &server.Request{Request: r}
-			res := &server.Response{w}
+			res := &server.Response{ResponseWriter: w}
 
-			err := HelpCallback(res, req, tc.jsonString)
-			if err != nil {
-				if tc.err == nil {
-					t.Errorf("Test Name: %s - Should not error - Got: %s", tc.name, err)
-				} else if err.Error() != tc.err.Error() {
-					t.Errorf("Test Name: %s - Should result in: %s - Got: %s", tc.name, tc.err, err)
-				}
+			// A failure to confirm must not reopen the modal for another submission
+			if err := HelpCallback(res, req, tc.ic); err != nil {
+				t.Fatalf("Should not error - Got: %s", err)
+			}
+			if _, err := ts.Get("HD-1"); err != nil {
+				t.Fatalf("Expected the ticket to be kept. Got %v", err)
 			}
+			mockSlack.AssertExpectations(t)
 		})
 	}
 }
 
+func TestHelpMention(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostThreadReply", wrapper.MessageRef{ChannelID: "C1AB2C3DE", Timestamp: "1503435956.000247"},
+		wrapper.Message{Text: "Thanks <@W12A3BCDEF>, we have received your help request *HD-1*"}).Return(wrapper.MessageRef{}, nil)
+	Init(mockSlack)
+	ts := store.NewMemory("HD")
+	InitStore(ts, "")
+
+	r := httptest.NewRequest("POST", "/slack", nil)
+	w := httptest.NewRecorder()
+	m := &server.Message{Channel: "C1AB2C3DE", User: "W12A3BCDEF", Text: "my VPN is down", TimeStamp: "1503435956.000247"}
+	if err := HelpMention(&server.Response{ResponseWriter: w}, &server.Request{Request: r}, m); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockSlack.AssertExpectations(t)
+	if tk, err := ts.Get("HD-1"); err != nil || tk.Requester != "W12A3BCDEF" || tk.Description != "my VPN is down" {
+		t.Fatalf("Expected a ticket to be raised. Got %+v %v", tk, err)
+	}
+}
+
 func TestHelpRequest(t *testing.T) {
 	mockSlack := &mocks.SlackWrapper{}
-	mockSlack.On("OpenDialog", "ABC123", mock.Anything).Return(nil)
+	mockSlack.On("OpenView", "ABC123", mock.Anything).Return(&slack.ViewResponse{}, nil)
 	Init(mockSlack)
 	sc := slack.SlashCommand{TriggerID: "ABC123"}
 	r := httptest.NewRequest("POST", "/slack", nil)
 	w := httptest.NewRecorder()
 	req := &server.Request{Request: r}
-	res := &server.Response{w}
+	res := &server.Response{ResponseWriter: w}
 
 	err := HelpRequest(res, req, sc)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s", err)
 	}
+	view := mockSlack.Calls[0].Arguments.Get(1).(slack.ModalViewRequest)
+	if view.CallbackID != "HelpRequest" {
+		t.Fatalf("Unexpected modal CallbackID: %s", view.CallbackID)
+	}
+}
+
+func TestHelpSubmissionFromView(t *testing.T) {
+	v := slack.View{
+		PrivateMetadata: "C1AB2C3DE",
+		State: &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+			helpDescriptionBlock: {helpDescriptionBlock: {Value: "My VPN is down"}},
+			helpUrgencyBlock:     {helpUrgencyBlock: {SelectedOption: slack.OptionBlockObject{Value: "high"}}},
+			helpAreasBlock: {helpAreasBlock: {SelectedOptions: []slack.OptionBlockObject{
+				{Value: "network"}, {Value: "software"},
+			}}},
+			helpNeededByBlock: {helpNeededByBlock: {SelectedDate: "2020-01-31"}},
+		}},
+	}
+	hs := helpSubmissionFromView(v)
+	if hs.Description != "My VPN is down" || hs.Urgency != "high" || hs.NeededBy != "2020-01-31" || hs.ChannelID != "C1AB2C3DE" {
+		t.Fatalf("Unexpected submission: %+v", hs)
+	}
+	if len(hs.Areas) != 2 || hs.Areas[0] != "network" || hs.Areas[1] != "software" {
+		t.Fatalf("Unexpected areas: %v", hs.Areas)
+	}
 }
 
 func TestHelpRequestErrors(t *testing.T) {
 	mockSlack := &mocks.SlackWrapper{}
-	mockSlack.On("OpenDialog", "ABC123", mock.Anything).Return(errors.New("bad thing happen"))
+	mockSlack.On("OpenView", "ABC123", mock.Anything).Return(nil, errors.New("bad thing happen"))
 	Init(mockSlack)
 	sc := slack.SlashCommand{TriggerID: "ABC123"}
 	r := httptest.NewRequest("POST", "/slack", nil)
 	w := httptest.NewRecorder()
 	req := &server.Request{Request: r}
-	res := &server.Response{w}
+	res := &server.Response{ResponseWriter: w}
 
-	err := HelpRequest(res, req, "foobar")
-	if err == nil {
-		t.Fatal("I expected that to error")
-	}
-
-	err = HelpRequest(res, req, sc)
+	err := HelpRequest(res, req, sc)
 	if err == nil {
 		t.Fatal("I expected that to error")
 	}
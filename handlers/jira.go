@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+)
+
+// Help request fields which can be mapped to JIRA fields using JiraConfig.FieldMappings
+const (
+	FieldRequester = "requester"
+	FieldUrgency   = "urgency"
+	FieldAreas     = "areas"
+	FieldNeededBy  = "needed_by"
+)
+
+// Ticket metadata keys linking a ticket to its JIRA issue
+const (
+	MetadataJiraKey = "jira_key"
+	MetadataJiraURL = "jira_url"
+)
+
+// JiraConfig controls how help requests are raised as JIRA issues
+type JiraConfig struct {
+	Project   string
+	IssueType string
+	Labels    []string
+	// FieldMappings maps help request fields (see FieldRequester etc.) to JIRA field IDs
+	// Areas are sent as a list of strings, every other field as a string
+	FieldMappings map[string]string
+}
+
+var (
+	jiraWrapper wrapper.JiraWrapper
+	jiraConfig  JiraConfig
+)
+
+// InitJira initialises the JIRA dependency used by JiraHelpCallback
+func InitJira(jw wrapper.JiraWrapper, cfg JiraConfig) {
+	jiraWrapper = jw
+	jiraConfig = cfg
+}
+
+// JiraHelpCallback is a handler that takes a view submission, generated by the HelpRequest
+// handler, records it as a ticket, raises it as a JIRA issue and posts the issue key back to
+// the requester. The ticket is kept if the issue can not be raised, so the request is not lost
+// Register it with server.Async as JIRA can be slower than Slack waits for a modal submission
+func JiraHelpCallback(res *server.Response, req *server.Request, ic *slack.InteractionCallback) error {
+	hs := helpSubmissionFromView(ic.View)
+
+	t, err := createTicket(ic.User, hs, nil)
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("Thanks <@%s>, we have received your help request *%s*", ic.User.ID, t.ID)
+	ref, err := jiraWrapper.CreateIssue(req.Context(), jiraIssue(ic.User, t.ID, hs))
+	if err == nil {
+		_, err = ticketStore.Modify(t.ID, func(stored *store.Ticket) error {
+			if stored.Metadata == nil {
+				stored.Metadata = map[string]string{}
+			}
+			stored.Metadata[MetadataJiraKey] = ref.Key
+			stored.Metadata[MetadataJiraURL] = ref.URL
+			return nil
+		})
+		if err != nil {
+			log.Errorf("Failed to link ticket %s to JIRA issue %s: %s", t.ID, ref.Key, err)
+		}
+		log.Printf("User: '%s' Requested Help: '%s' Ticket: '%s' Raised: '%s'", ic.User.Name, hs.Description, t.ID, ref.Key)
+		text += fmt.Sprintf(" and raised <%s|%s>", ref.URL, ref.Key)
+	} else {
+		log.Errorf("Failed to raise JIRA issue for ticket %s: %s", t.ID, err)
+	}
+
+	msg := wrapper.Message{Text: fmt.Sprintf("%s:\n>%s", text, hs.Description)}
+	// The ticket has been saved, failing would invite the user to submit it again
+	if err := confirm(hs.ChannelID, ic.User.ID, msg); err != nil {
+		log.Errorf("Failed to confirm help request %s: %s", t.ID, err)
+	}
+	return nil
+}
+
+// jiraIssue builds a JIRA issue from a help request using the configured project, issue type,
+// labels and field mappings. The description refers back to the help desk ticket
+func jiraIssue(u slack.User, ticketID string, hs HelpSubmission) wrapper.JiraIssue {
+	values := map[string]interface{}{
+		FieldRequester: u.Name,
+		FieldUrgency:   hs.Urgency,
+		FieldAreas:     hs.Areas,
+		FieldNeededBy:  hs.NeededBy,
+	}
+	fields := map[string]interface{}{}
+	for k, id := range jiraConfig.FieldMappings {
+		// Optional fields which were left empty are not sent, JIRA rejects empty dates
+		if v, ok := values[k]; ok && !isEmpty(v) {
+			fields[id] = v
+		}
+	}
+
+	description := fmt.Sprintf("%s\n\nRequested by %s (%s) via Slack, help desk ticket %s", hs.Description, u.Name, u.ID, ticketID)
+	return wrapper.JiraIssue{
+		Project:     jiraConfig.Project,
+		IssueType:   jiraConfig.IssueType,
+		Summary:     summarise(hs.Description),
+		Description: description,
+		Labels:      jiraConfig.Labels,
+		Fields:      fields,
+	}
+}
+
+// isEmpty reports whether a help request field was left unset
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	}
+	return v == nil
+}
+
+// summarise returns the first line of s, truncated to a length suitable for a JIRA summary
+func summarise(s string) string {
+	const max = 80
+	s = strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skybet/go-helpdesk/mocks"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+func helpSubmissionCallback() *slack.InteractionCallback {
+	return &slack.InteractionCallback{
+		Type: slack.InteractionTypeViewSubmission,
+		User: slack.User{ID: "W12A3BCDEF", Name: "dreamweaver"},
+		View: slack.View{
+			CallbackID:      "HelpRequest",
+			PrivateMetadata: "C1AB2C3DE",
+			State: &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+				helpDescriptionBlock: {helpDescriptionBlock: {Value: "My VPN is down\nIt stopped working this morning"}},
+				helpUrgencyBlock:     {helpUrgencyBlock: {SelectedOption: slack.OptionBlockObject{Value: "high"}}},
+				helpAreasBlock:       {helpAreasBlock: {SelectedOptions: []slack.OptionBlockObject{{Value: "network"}}}},
+			}},
+		},
+	}
+}
+
+func TestJiraHelpCallback(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	mockJira := &mocks.JiraWrapper{}
+	ts := store.NewMemory("HD")
+	Init(mockSlack)
+	InitStore(ts, "")
+	InitJira(mockJira, JiraConfig{
+		Project:       "HELP",
+		IssueType:     "Task",
+		Labels:        []string{"helpdesk"},
+		FieldMappings: map[string]string{FieldUrgency: "customfield_10010", FieldAreas: "customfield_10020", FieldNeededBy: "duedate"},
+	})
+	mockJira.On("CreateIssue", mock.Anything, mock.Anything).Return(&wrapper.JiraIssueRef{Key: "HELP-24", URL: "https://jira/browse/HELP-24"}, nil)
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", mock.Anything).Return(nil)
+
+	req := &server.Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	res := &server.Response{ResponseWriter: httptest.NewRecorder()}
+	if err := JiraHelpCallback(res, req, helpSubmissionCallback()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	issue := mockJira.Calls[0].Arguments.Get(1).(wrapper.JiraIssue)
+	if issue.Project != "HELP" || issue.IssueType != "Task" || issue.Summary != "My VPN is down" || !strings.HasSuffix(issue.Description, "help desk ticket HD-1") {
+		t.Fatalf("Unexpected issue: %+v", issue)
+	}
+	if !reflect.DeepEqual(issue.Labels, []string{"helpdesk"}) {
+		t.Fatalf("Unexpected labels: %v", issue.Labels)
+	}
+	expected := map[string]interface{}{"customfield_10010": "high", "customfield_10020": []string{"network"}}
+	if !reflect.DeepEqual(issue.Fields, expected) {
+		t.Fatalf("Unexpected fields: %v", issue.Fields)
+	}
+
+	msg := mockSlack.Calls[1].Arguments.Get(2).(wrapper.Message)
+	if !strings.Contains(msg.Text, "*HD-1* and raised <https://jira/browse/HELP-24|HELP-24>") {
+		t.Fatalf("Expected the ticket ID and issue key to be posted back to Slack. Got: %s", msg.Text)
+	}
+
+	ticket, err := ts.Get("HD-1")
+	if err != nil {
+		t.Fatalf("Expected the help request to be recorded: %s", err)
+	}
+	if ticket.Requester != "W12A3BCDEF" || ticket.Metadata[MetadataJiraKey] != "HELP-24" {
+		t.Fatalf("Unexpected ticket: %+v", ticket)
+	}
+}
+
+func TestJiraHelpCallbackConfirmsThroughSlack(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	mockJira := &mocks.JiraWrapper{}
+	Init(mockSlack)
+	InitStore(store.NewMemory("HD"), "")
+	InitJira(mockJira, JiraConfig{Project: "HELP", IssueType: "Task"})
+	mockJira.On("CreateIssue", mock.Anything, mock.Anything).Return(&wrapper.JiraIssueRef{Key: "HELP-24", URL: "https://jira/browse/HELP-24"}, nil)
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", mock.Anything).Return(nil)
+
+	// The route is Async, so Slack has already been answered and nothing must be written
+	w := httptest.NewRecorder()
+	req := &server.Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	if err := JiraHelpCallback(&server.Response{ResponseWriter: w}, req, helpSubmissionCallback()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if w.Body.Len() != 0 || len(w.Header()) != 0 {
+		t.Fatalf("Expected nothing to be written to the response. Got %v %q", w.Header(), w.Body.String())
+	}
+	mockSlack.AssertCalled(t, "PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", mock.Anything)
+}
+
+func TestJiraHelpCallbackErrors(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	mockJira := &mocks.JiraWrapper{}
+	ts := store.NewMemory("HD")
+	Init(mockSlack)
+	InitStore(ts, "")
+	InitJira(mockJira, JiraConfig{Project: "HELP", IssueType: "Task"})
+	mockJira.On("CreateIssue", mock.Anything, mock.Anything).Return(nil, errors.New("bad thing happen"))
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostEphemeral", "C1AB2C3DE", "W12A3BCDEF", mock.Anything).Return(nil)
+
+	req := &server.Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	res := &server.Response{ResponseWriter: httptest.NewRecorder()}
+	if err := JiraHelpCallback(res, req, helpSubmissionCallback()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The help request is still recorded and confirmed without an issue
+	ticket, err := ts.Get("HD-1")
+	if err != nil || ticket.Metadata[MetadataJiraKey] != "" {
+		t.Fatalf("Expected the ticket to be kept without an issue. Got %+v %v", ticket, err)
+	}
+	msg := mockSlack.Calls[1].Arguments.Get(2).(wrapper.Message)
+	if !strings.HasPrefix(msg.Text, "Thanks <@W12A3BCDEF>, we have received your help request *HD-1*:") {
+		t.Fatalf("Unexpected confirmation: %s", msg.Text)
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	if s := summarise(long); len(s) != 80 || !strings.HasSuffix(s, "...") {
+		t.Fatalf("Expected a truncated summary. Got: %s", s)
+	}
+	if s := summarise("  first line \nsecond line"); s != "first line" {
+		t.Fatalf("Expected the first line only. Got: %s", s)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/wrapper"
+)
+
+// Action IDs of the buttons attached to a page message
+const (
+	PageAcknowledgeAction = "pagerduty_acknowledge"
+	PageResolveAction     = "pagerduty_resolve"
+)
+
+var pagerDutyWrapper wrapper.PagerDutyWrapper
+
+// InitPagerDuty initialises the PagerDuty dependency used by the Page handlers
+func InitPagerDuty(pw wrapper.PagerDutyWrapper) {
+	pagerDutyWrapper = pw
+}
+
+// dedupKey links a page message in Slack to the PagerDuty incident it triggered
+func dedupKey(ref wrapper.MessageRef) string {
+	return fmt.Sprintf("slack-%s-%s", ref.ChannelID, ref.Timestamp)
+}
+
+// Page is a handler for a slash command which pages on-call through PagerDuty
+// A message with acknowledge and resolve buttons is posted to the channel and the
+// incident is triggered with a dedup key derived from that message
+func Page(res *server.Response, req *server.Request, sc slack.SlashCommand) error {
+	summary := strings.TrimSpace(sc.Text)
+	if summary == "" {
+		return server.ReplyEphemeral(res, req, &slack.Msg{Text: fmt.Sprintf("Usage: `%s <description of the problem>`", sc.Command)})
+	}
+
+	text := fmt.Sprintf(":rotating_light: <@%s> paged on-call: %s", sc.UserID, summary)
+	ref, err := slackWrapper.PostMessage(sc.ChannelID, wrapper.Message{Text: text, Blocks: pageBlocks(text)})
+	if err != nil {
+		return fmt.Errorf("Failed to post page message: %s", err)
+	}
+
+	_, err = pagerDutyWrapper.Trigger(req.Context(), wrapper.PagerDutyEvent{
+		DedupKey: dedupKey(ref),
+		Summary:  summary,
+		Source:   "go-helpdesk",
+		Severity: "critical",
+		CustomDetails: map[string]string{
+			"requester":     sc.UserName,
+			"slack_channel": ref.ChannelID,
+			"slack_ts":      ref.Timestamp,
+		},
+	})
+	if err != nil {
+		failed := fmt.Sprintf(":warning: <@%s> tried to page on-call but PagerDuty could not be reached: %s", sc.UserID, summary)
+		if _, uerr := slackWrapper.UpdateMessage(ref, wrapper.Message{Text: failed}); uerr != nil {
+			return fmt.Errorf("Failed to trigger PagerDuty incident: %s (and failed to update message: %s)", err, uerr)
+		}
+		return fmt.Errorf("Failed to trigger PagerDuty incident: %s", err)
+	}
+	return nil
+}
+
+// PageAcknowledge is a handler for the acknowledge button on a page message
+func PageAcknowledge(res *server.Response, req *server.Request, b *server.BlockActionCallback) error {
+	ref := actionMessageRef(b)
+	if err := pagerDutyWrapper.Acknowledge(req.Context(), dedupKey(ref)); err != nil {
+		return fmt.Errorf("Failed to acknowledge PagerDuty incident: %s", err)
+	}
+	reply := wrapper.Message{Text: fmt.Sprintf(":eyes: <@%s> acknowledged the incident", b.User.ID)}
+	if _, err := slackWrapper.PostThreadReply(ref, reply); err != nil {
+		return fmt.Errorf("Failed to post acknowledgement: %s", err)
+	}
+	return nil
+}
+
+// PageResolve is a handler for the resolve button on a page message
+// The buttons are removed from the message once the incident is resolved
+func PageResolve(res *server.Response, req *server.Request, b *server.BlockActionCallback) error {
+	ref := actionMessageRef(b)
+	if err := pagerDutyWrapper.Resolve(req.Context(), dedupKey(ref)); err != nil {
+		return fmt.Errorf("Failed to resolve PagerDuty incident: %s", err)
+	}
+
+	resolved := slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(":white_check_mark: Resolved by <@%s>", b.User.ID), false, false))
+	msg := wrapper.Message{Text: b.Message.Text, Blocks: []slack.Block{pageSection(b.Message.Text), resolved}}
+	if _, err := slackWrapper.UpdateMessage(ref, msg); err != nil {
+		return fmt.Errorf("Failed to update page message: %s", err)
+	}
+	reply := wrapper.Message{Text: fmt.Sprintf(":white_check_mark: <@%s> resolved the incident", b.User.ID)}
+	if _, err := slackWrapper.PostThreadReply(ref, reply); err != nil {
+		return fmt.Errorf("Failed to post resolution: %s", err)
+	}
+	return nil
+}
+
+// actionMessageRef returns a reference to the message a button was clicked on
+func actionMessageRef(b *server.BlockActionCallback) wrapper.MessageRef {
+	channelID := b.Container.ChannelID
+	if channelID == "" {
+		channelID = b.Channel.ID
+	}
+	return wrapper.MessageRef{ChannelID: channelID, Timestamp: b.Container.MessageTs}
+}
+
+func pageSection(text string) slack.Block {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
+
+// pageBlocks builds the content of a page message
+func pageBlocks(text string) []slack.Block {
+	ack := slack.NewButtonBlockElement(PageAcknowledgeAction, "acknowledge", slack.NewTextBlockObject(slack.PlainTextType, "Acknowledge", false, false))
+	ack.Style = slack.StylePrimary
+	resolve := slack.NewButtonBlockElement(PageResolveAction, "resolve", slack.NewTextBlockObject(slack.PlainTextType, "Resolve", false, false))
+	resolve.Style = slack.StyleDanger
+	return []slack.Block{pageSection(text), slack.NewActionBlock("page", ack, resolve)}
+}
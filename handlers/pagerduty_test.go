@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skybet/go-helpdesk/mocks"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/wrapper"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+var pageRefFixture = wrapper.MessageRef{ChannelID: "C1AB2C3DE", Timestamp: "1503435956.000247"}
+
+func newPageMocks() (*mocks.SlackWrapper, *mocks.PagerDutyWrapper) {
+	mockSlack := &mocks.SlackWrapper{}
+	mockPD := &mocks.PagerDutyWrapper{}
+	Init(mockSlack)
+	InitPagerDuty(mockPD)
+	return mockSlack, mockPD
+}
+
+func newTestRequest() (*server.Response, *server.Request) {
+	req := &server.Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	res := &server.Response{ResponseWriter: httptest.NewRecorder()}
+	return res, req
+}
+
+func pageButton(actionID string) *server.BlockActionCallback {
+	return &server.BlockActionCallback{
+		InteractionCallback: &slack.InteractionCallback{
+			User:      slack.User{ID: "W12A3BCDEF"},
+			Container: slack.Container{ChannelID: "C1AB2C3DE", MessageTs: "1503435956.000247"},
+			Message:   slack.Message{Msg: slack.Msg{Text: ":rotating_light: <@UABC123> paged on-call: VPN is down"}},
+		},
+		Action: &slack.BlockAction{ActionID: actionID},
+	}
+}
+
+func TestPage(t *testing.T) {
+	mockSlack, mockPD := newPageMocks()
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(pageRefFixture, nil)
+	mockPD.On("Trigger", mock.Anything, mock.Anything).Return("slack-C1AB2C3DE-1503435956.000247", nil)
+
+	res, req := newTestRequest()
+	sc := slack.SlashCommand{Command: "/page", Text: "VPN is down", ChannelID: "C1AB2C3DE", UserID: "UABC123", UserName: "bob"}
+	if err := Page(res, req, sc); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	event := mockPD.Calls[0].Arguments.Get(1).(wrapper.PagerDutyEvent)
+	if event.DedupKey != "slack-C1AB2C3DE-1503435956.000247" || event.Summary != "VPN is down" {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+	msg := mockSlack.Calls[0].Arguments.Get(1).(wrapper.Message)
+	if len(msg.Blocks) != 2 {
+		t.Fatalf("Expected the page message to include buttons")
+	}
+}
+
+func TestPageUsage(t *testing.T) {
+	mockSlack, mockPD := newPageMocks()
+
+	res, req := newTestRequest()
+	sc := slack.SlashCommand{Command: "/page", Text: "  ", ChannelID: "C1AB2C3DE", UserID: "UABC123"}
+	if err := Page(res, req, sc); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// The usage is sent as the response, which does not need the bot to be in the channel
+	var msg slack.Msg
+	json.NewDecoder(res.ResponseWriter.(*httptest.ResponseRecorder).Body).Decode(&msg)
+	if msg.ResponseType != "ephemeral" || msg.Text != "Usage: `/page <description of the problem>`" {
+		t.Fatalf("Unexpected usage message: %+v", msg)
+	}
+	mockSlack.AssertNotCalled(t, "PostEphemeral", mock.Anything, mock.Anything, mock.Anything)
+	mockPD.AssertNotCalled(t, "Trigger", mock.Anything, mock.Anything)
+}
+
+func TestPageTriggerFailure(t *testing.T) {
+	mockSlack, mockPD := newPageMocks()
+	mockSlack.On("PostMessage", "C1AB2C3DE", mock.Anything).Return(pageRefFixture, nil)
+	mockSlack.On("UpdateMessage", pageRefFixture, mock.Anything).Return(pageRefFixture, nil)
+	mockPD.On("Trigger", mock.Anything, mock.Anything).Return("", errors.New("bad thing happen"))
+
+	res, req := newTestRequest()
+	sc := slack.SlashCommand{Command: "/page", Text: "VPN is down", ChannelID: "C1AB2C3DE", UserID: "UABC123"}
+	err := Page(res, req, sc)
+	if err == nil || err.Error() != "Failed to trigger PagerDuty incident: bad thing happen" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mockSlack.AssertExpectations(t)
+}
+
+func TestPageAcknowledge(t *testing.T) {
+	mockSlack, mockPD := newPageMocks()
+	mockPD.On("Acknowledge", mock.Anything, "slack-C1AB2C3DE-1503435956.000247").Return(nil)
+	mockSlack.On("PostThreadReply", pageRefFixture, mock.Anything).Return(wrapper.MessageRef{}, nil)
+
+	res, req := newTestRequest()
+	if err := PageAcknowledge(res, req, pageButton(PageAcknowledgeAction)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockSlack.AssertExpectations(t)
+	mockPD.AssertExpectations(t)
+}
+
+func TestPageResolve(t *testing.T) {
+	mockSlack, mockPD := newPageMocks()
+	mockPD.On("Resolve", mock.Anything, "slack-C1AB2C3DE-1503435956.000247").Return(nil)
+	mockSlack.On("UpdateMessage", pageRefFixture, mock.Anything).Return(pageRefFixture, nil)
+	mockSlack.On("PostThreadReply", pageRefFixture, mock.Anything).Return(wrapper.MessageRef{}, nil)
+
+	res, req := newTestRequest()
+	if err := PageResolve(res, req, pageButton(PageResolveAction)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockSlack.AssertExpectations(t)
+	mockPD.AssertExpectations(t)
+
+	msg := mockSlack.Calls[0].Arguments.Get(1).(wrapper.Message)
+	for _, b := range msg.Blocks {
+		if b.BlockType() == slack.MBTAction {
+			t.Fatalf("Expected the buttons to be removed once resolved")
+		}
+	}
+}
+
+func TestPageActionErrors(t *testing.T) {
+	_, mockPD := newPageMocks()
+	mockPD.On("Acknowledge", mock.Anything, mock.Anything).Return(errors.New("bad thing happen"))
+
+	res, req := newTestRequest()
+	err := PageAcknowledge(res, req, pageButton(PageAcknowledgeAction))
+	if err == nil || err.Error() != "Failed to acknowledge PagerDuty incident: bad thing happen" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+
+	"github.com/skybet/go-helpdesk/lifecycle"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+)
+
+// Action IDs of the buttons attached to a ticket message. The value of each button is the ticket ID
+const (
+	TicketClaimAction   = "ticket_claim"
+	TicketResolveAction = "ticket_resolve"
+	TicketReopenAction  = "ticket_reopen"
+)
+
+// Ticket metadata keys locating the ticket message in Slack
+const (
+	MetadataSlackChannel = "slack_channel"
+	MetadataSlackTs      = "slack_ts"
+)
+
+var (
+	ticketStore   store.TicketStore
+	ticketChannel string
+)
+
+// InitStore initialises the store in which help requests are recorded
+// Ticket messages with lifecycle controls are posted to channelID, or to the channel
+// the request was raised from if channelID is empty
+func InitStore(ts store.TicketStore, channelID string) {
+	ticketStore = ts
+	ticketChannel = channelID
+}
+
+// createTicket records a help request in the ticket store and posts a ticket message
+// with lifecycle controls to Slack. The help request has been recorded once the ticket is
+// stored, so a failure to post the message is logged rather than returned
+func createTicket(u slack.User, hs HelpSubmission, metadata map[string]string) (*store.Ticket, error) {
+	t := &store.Ticket{
+		Status:      store.StatusNew,
+		Requester:   u.ID,
+		Description: hs.Description,
+		Urgency:     hs.Urgency,
+		Areas:       hs.Areas,
+		NeededBy:    hs.NeededBy,
+		ChannelID:   hs.ChannelID,
+		Metadata:    metadata,
+	}
+	if err := ticketStore.Create(t); err != nil {
+		return nil, fmt.Errorf("Failed to record help request: %s", err)
+	}
+
+	channelID := ticketChannel
+	if channelID == "" {
+		channelID = hs.ChannelID
+	}
+	if channelID == "" {
+		return t, nil
+	}
+	ref, err := slackWrapper.PostMessage(channelID, ticketMessage(t))
+	if err != nil {
+		log.Errorf("Failed to post ticket %s: %s", t.ID, err)
+		return t, nil
+	}
+	updated, err := ticketStore.Modify(t.ID, func(stored *store.Ticket) error {
+		if stored.Metadata == nil {
+			stored.Metadata = map[string]string{}
+		}
+		stored.Metadata[MetadataSlackChannel] = ref.ChannelID
+		stored.Metadata[MetadataSlackTs] = ref.Timestamp
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to record ticket message for %s: %s", t.ID, err)
+		return t, nil
+	}
+	return updated, nil
+}
+
+// TicketClaim is a handler for the claim button on a ticket message
+func TicketClaim(res *server.Response, req *server.Request, b *server.BlockActionCallback) error {
+	return ticketAction(b, "claimed", lifecycle.Claim)
+}
+
+// TicketResolve is a handler for the resolve button on a ticket message
+func TicketResolve(res *server.Response, req *server.Request, b *server.BlockActionCallback) error {
+	return ticketAction(b, "resolved", lifecycle.Resolve)
+}
+
+// TicketReopen is a handler for the reopen button on a ticket message
+func TicketReopen(res *server.Response, req *server.Request, b *server.BlockActionCallback) error {
+	return ticketAction(b, "reopened", lifecycle.Reopen)
+}
+
+// ticketAction applies a lifecycle action to the ticket a button belongs to, refreshes the
+// ticket message and records who made the change in its thread
+func ticketAction(b *server.BlockActionCallback, verb string, action func(*store.Ticket, string) error) error {
+	id := b.Action.Value
+	ref := actionMessageRef(b)
+
+	t, err := lifecycle.Apply(ticketStore, id, b.User.ID, action)
+	if te, ok := err.(*lifecycle.TransitionError); ok {
+		// The message was out of date, let the user know rather than treating it as a failure
+		msg := wrapper.Message{Text: fmt.Sprintf("%s can not be %s as it is %s", id, verb, strings.ToLower(lifecycle.Label(te.From)))}
+		return slackWrapper.PostEphemeral(ref.ChannelID, b.User.ID, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to update ticket %s: %s", id, err)
+	}
+	log.Printf("User: '%s' %s ticket: '%s'", b.User.Name, verb, t.ID)
+	return refreshTicket(ref, t, fmt.Sprintf("<@%s> %s *%s*", b.User.ID, verb, t.ID))
+}
+
+// ticketRef returns a reference to the message a ticket was posted as, if it has one
+func ticketRef(t *store.Ticket) (wrapper.MessageRef, bool) {
+	ref := wrapper.MessageRef{ChannelID: t.Metadata[MetadataSlackChannel], Timestamp: t.Metadata[MetadataSlackTs]}
+	return ref, ref.ChannelID != "" && ref.Timestamp != ""
+}
+
+// refreshTicket updates the ticket message at ref to reflect t and posts update in its thread
+func refreshTicket(ref wrapper.MessageRef, t *store.Ticket, update string) error {
+	if _, err := slackWrapper.UpdateMessage(ref, ticketMessage(t)); err != nil {
+		return fmt.Errorf("Failed to update ticket message for %s: %s", t.ID, err)
+	}
+	if _, err := slackWrapper.PostThreadReply(ref, wrapper.Message{Text: update}); err != nil {
+		return fmt.Errorf("Failed to post ticket update for %s: %s", t.ID, err)
+	}
+	return nil
+}
+
+// ticketMessage builds the Slack message showing a ticket and the controls valid for its state
+func ticketMessage(t *store.Ticket) wrapper.Message {
+	mrkdwn := func(s string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.MarkdownType, s, false, false)
+	}
+	button := func(actionID, text string) *slack.ButtonBlockElement {
+		return slack.NewButtonBlockElement(actionID, t.ID, slack.NewTextBlockObject(slack.PlainTextType, text, false, false))
+	}
+
+	text := fmt.Sprintf("%s from <@%s>: %s", t.ID, t.Requester, lifecycle.Label(t.Status))
+	fields := []*slack.TextBlockObject{mrkdwn(fmt.Sprintf("*Status*\n%s", lifecycle.Label(t.Status)))}
+	if t.Assignee != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Assignee*\n<@%s>", t.Assignee)))
+	}
+	if t.Urgency != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Urgency*\n%s", t.Urgency)))
+	}
+	if t.NeededBy != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Needed by*\n%s", t.NeededBy)))
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(mrkdwn(fmt.Sprintf("*%s* from <@%s>\n>%s", t.ID, t.Requester, t.Description)), fields, nil),
+	}
+
+	var buttons []slack.BlockElement
+	if lifecycle.IsOpen(t.Status) && t.Status != lifecycle.InProgress {
+		claim := button(TicketClaimAction, "Claim")
+		claim.Style = slack.StylePrimary
+		buttons = append(buttons, claim)
+	}
+	if lifecycle.CanTransition(t.Status, lifecycle.Resolved) {
+		buttons = append(buttons, button(TicketResolveAction, "Resolve"))
+	}
+	if !lifecycle.IsOpen(t.Status) {
+		buttons = append(buttons, button(TicketReopenAction, "Reopen"))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, slack.NewActionBlock("ticket", buttons...))
+	}
+	return wrapper.Message{Text: text, Blocks: blocks}
+}
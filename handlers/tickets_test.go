@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/skybet/go-helpdesk/lifecycle"
+	"github.com/skybet/go-helpdesk/mocks"
+	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
+	"github.com/skybet/go-helpdesk/wrapper"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+var ticketRefFixture = wrapper.MessageRef{ChannelID: "C0TICKETS", Timestamp: "1503435957.000111"}
+
+func ticketButton(actionID, ticketID string) *server.BlockActionCallback {
+	return &server.BlockActionCallback{
+		InteractionCallback: &slack.InteractionCallback{
+			User:      slack.User{ID: "W0AGENT", Name: "agent"},
+			Container: slack.Container{ChannelID: "C0TICKETS", MessageTs: "1503435957.000111"},
+		},
+		Action: &slack.BlockAction{ActionID: actionID, Value: ticketID},
+	}
+}
+
+func newTicketMocks(t *testing.T) (*mocks.SlackWrapper, store.TicketStore) {
+	mockSlack := &mocks.SlackWrapper{}
+	ts := store.NewMemory("HD")
+	Init(mockSlack)
+	InitStore(ts, "C0TICKETS")
+	if err := ts.Create(&store.Ticket{Status: store.StatusNew, Requester: "W12A3BCDEF", Description: "My VPN is down"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return mockSlack, ts
+}
+
+// buttons returns the action IDs of the buttons on a ticket message
+func buttons(msg wrapper.Message) []string {
+	var ids []string
+	for _, b := range msg.Blocks {
+		if a, ok := b.(*slack.ActionBlock); ok {
+			for _, e := range a.Elements.ElementSet {
+				ids = append(ids, e.(*slack.ButtonBlockElement).ActionID)
+			}
+		}
+	}
+	return ids
+}
+
+func TestCreateTicketPostsToTicketChannel(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	ts := store.NewMemory("HD")
+	Init(mockSlack)
+	InitStore(ts, "C0TICKETS")
+	mockSlack.On("PostMessage", "C0TICKETS", mock.Anything).Return(ticketRefFixture, nil)
+
+	tk, err := createTicket(slack.User{ID: "W12A3BCDEF"}, HelpSubmission{Description: "My VPN is down", ChannelID: "C1AB2C3DE"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	saved, _ := ts.Get(tk.ID)
+	if saved.Metadata[MetadataSlackChannel] != "C0TICKETS" || saved.Metadata[MetadataSlackTs] != "1503435957.000111" {
+		t.Fatalf("Expected the ticket message to be recorded. Got: %v", saved.Metadata)
+	}
+	msg := mockSlack.Calls[0].Arguments.Get(1).(wrapper.Message)
+	if got := strings.Join(buttons(msg), ","); got != "ticket_claim,ticket_resolve" {
+		t.Fatalf("Unexpected buttons on a new ticket: %s", got)
+	}
+}
+
+func TestCreateTicketPostFailure(t *testing.T) {
+	mockSlack := &mocks.SlackWrapper{}
+	ts := store.NewMemory("HD")
+	Init(mockSlack)
+	InitStore(ts, "C0TICKETS")
+	mockSlack.On("PostMessage", "C0TICKETS", mock.Anything).Return(wrapper.MessageRef{}, errors.New("channel_not_found"))
+
+	tk, err := createTicket(slack.User{ID: "W12A3BCDEF"}, HelpSubmission{Description: "My VPN is down"}, nil)
+	if err != nil || tk == nil || tk.ID != "HD-1" {
+		t.Fatalf("Expected the stored ticket to be returned. Got %+v %v", tk, err)
+	}
+	if _, ok := ticketRef(tk); ok {
+		t.Fatalf("Expected the ticket to have no message")
+	}
+}
+
+func TestTicketLifecycle(t *testing.T) {
+	mockSlack, ts := newTicketMocks(t)
+	mockSlack.On("UpdateMessage", ticketRefFixture, mock.Anything).Return(ticketRefFixture, nil)
+	mockSlack.On("PostThreadReply", ticketRefFixture, mock.Anything).Return(wrapper.MessageRef{}, nil)
+	res, req := newTestRequest()
+
+	steps := []struct {
+		handler server.BlockActionHandlerFunc
+		action  string
+		status  store.Status
+		buttons string
+		reply   string
+	}{
+		{TicketClaim, TicketClaimAction, lifecycle.InProgress, "ticket_resolve", "<@W0AGENT> claimed *HD-1*"},
+		{TicketResolve, TicketResolveAction, lifecycle.Resolved, "ticket_reopen", "<@W0AGENT> resolved *HD-1*"},
+		{TicketReopen, TicketReopenAction, lifecycle.InProgress, "ticket_resolve", "<@W0AGENT> reopened *HD-1*"},
+	}
+	for i, s := range steps {
+		if err := s.handler(res, req, ticketButton(s.action, "HD-1")); err != nil {
+			t.Fatalf("Unexpected error from %s: %s", s.action, err)
+		}
+		tk, _ := ts.Get("HD-1")
+		if tk.Status != s.status || tk.Assignee != "W0AGENT" {
+			t.Fatalf("Unexpected ticket after %s: %+v", s.action, tk)
+		}
+		msg := mockSlack.Calls[i*2].Arguments.Get(1).(wrapper.Message)
+		if got := strings.Join(buttons(msg), ","); got != s.buttons {
+			t.Fatalf("Unexpected buttons after %s: %s", s.action, got)
+		}
+		reply := mockSlack.Calls[i*2+1].Arguments.Get(1).(wrapper.Message)
+		if reply.Text != s.reply {
+			t.Fatalf("Unexpected reply after %s: %s", s.action, reply.Text)
+		}
+	}
+	tk, _ := ts.Get("HD-1")
+	if len(tk.History) != 3 {
+		t.Fatalf("Expected each transition to be recorded. Got: %+v", tk.History)
+	}
+}
+
+func TestTicketInvalidTransition(t *testing.T) {
+	mockSlack, ts := newTicketMocks(t)
+	mockSlack.On("PostEphemeral", "C0TICKETS", "W0AGENT", wrapper.Message{Text: "HD-1 can not be reopened as it is new"}).Return(nil)
+
+	res, req := newTestRequest()
+	if err := TicketReopen(res, req, ticketButton(TicketReopenAction, "HD-1")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockSlack.AssertExpectations(t)
+	if tk, _ := ts.Get("HD-1"); tk.Status != store.StatusNew {
+		t.Fatalf("Ticket should not have changed: %+v", tk)
+	}
+}
+
+func TestTicketActionErrors(t *testing.T) {
+	mockSlack, _ := newTicketMocks(t)
+	mockSlack.On("UpdateMessage", ticketRefFixture, mock.Anything).Return(wrapper.MessageRef{}, errors.New("bad thing happen"))
+
+	res, req := newTestRequest()
+	err := TicketClaim(res, req, ticketButton(TicketClaimAction, "HD-42"))
+	if err == nil || err.Error() != "Failed to update ticket HD-42: ticket not found" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = TicketClaim(res, req, ticketButton(TicketClaimAction, "HD-1"))
+	if err == nil || err.Error() != "Failed to update ticket message for HD-1: bad thing happen" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package lifecycle defines the states a help request ticket moves through and
+// the transitions allowed between them
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skybet/go-helpdesk/store"
+)
+
+// The states of a ticket
+const (
+	New                = store.StatusNew
+	Triaged            = store.Status("triaged")
+	InProgress         = store.Status("in_progress")
+	WaitingOnRequester = store.Status("waiting_on_requester")
+	Resolved           = store.Status("resolved")
+	Closed             = store.Status("closed")
+)
+
+// transitions lists the states which can be reached from each state
+var transitions = map[store.Status][]store.Status{
+	New:                {Triaged, InProgress, Resolved, Closed},
+	Triaged:            {InProgress, Resolved, Closed},
+	InProgress:         {Triaged, WaitingOnRequester, Resolved},
+	WaitingOnRequester: {InProgress, Resolved, Closed},
+	Resolved:           {New, InProgress, Closed},
+	Closed:             {New, InProgress},
+}
+
+// TransitionError is returned when a ticket can not move to the requested state
+type TransitionError struct {
+	From, To store.Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("can not move ticket from %s to %s", e.From, e.To)
+}
+
+// CanTransition reports whether a ticket may move from one state to another
+func CanTransition(from, to store.Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether a ticket in state s still needs attention
+func IsOpen(s store.Status) bool {
+	return s != Resolved && s != Closed
+}
+
+// Label returns a human readable name for a state
+func Label(s store.Status) string {
+	switch s {
+	case New:
+		return "New"
+	case Triaged:
+		return "Triaged"
+	case InProgress:
+		return "In progress"
+	case WaitingOnRequester:
+		return "Waiting on requester"
+	case Resolved:
+		return "Resolved"
+	case Closed:
+		return "Closed"
+	default:
+		return string(s)
+	}
+}
+
+// Transition moves t to state to, recording actor and the time in its history
+// A *TransitionError is returned if the move is not allowed
+func Transition(t *store.Ticket, to store.Status, actor string) error {
+	if !CanTransition(t.Status, to) {
+		return &TransitionError{From: t.Status, To: to}
+	}
+	t.History = append(t.History, store.Transition{From: t.Status, To: to, Actor: actor, At: time.Now()})
+	t.Status = to
+	return nil
+}
+
+// Claim assigns t to actor and moves it in to progress
+func Claim(t *store.Ticket, actor string) error {
+	if err := Transition(t, InProgress, actor); err != nil {
+		return err
+	}
+	t.Assignee = actor
+	return nil
+}
+
+// Assign returns an action which assigns an open ticket to assignee, moving it in to
+// progress if it is not already. Resolved and closed tickets must be reopened first
+func Assign(assignee string) func(*store.Ticket, string) error {
+	return func(t *store.Ticket, actor string) error {
+		if !IsOpen(t.Status) {
+			return &TransitionError{From: t.Status, To: InProgress}
+		}
+		if t.Status != InProgress {
+			if err := Transition(t, InProgress, actor); err != nil {
+				return err
+			}
+		}
+		t.Assignee = assignee
+		return nil
+	}
+}
+
+// Resolve marks t as resolved by actor
+func Resolve(t *store.Ticket, actor string) error {
+	return Transition(t, Resolved, actor)
+}
+
+// Reopen returns a resolved or closed ticket to work. Tickets which were assigned go
+// straight back in to progress, otherwise they need triaging again as new tickets
+func Reopen(t *store.Ticket, actor string) error {
+	to := New
+	if t.Assignee != "" {
+		to = InProgress
+	}
+	if IsOpen(t.Status) {
+		return &TransitionError{From: t.Status, To: to}
+	}
+	return Transition(t, to, actor)
+}
+
+// Apply loads the ticket with the given ID, applies action to it and saves the result
+// The ticket is modified atomically so concurrent actions can not overwrite each other
+func Apply(ts store.TicketStore, id, actor string, action func(*store.Ticket, string) error) (*store.Ticket, error) {
+	return ts.Modify(id, func(t *store.Ticket) error {
+		return action(t, actor)
+	})
+}
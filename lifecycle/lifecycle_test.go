@@ -0,0 +1,145 @@
+package lifecycle
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/skybet/go-helpdesk/store"
+)
+
+func TestCanTransition(t *testing.T) {
+	tt := []struct {
+		from, to store.Status
+		ok       bool
+	}{
+		{New, Triaged, true},
+		{Triaged, InProgress, true},
+		{InProgress, WaitingOnRequester, true},
+		{WaitingOnRequester, InProgress, true},
+		{InProgress, Resolved, true},
+		{Resolved, Closed, true},
+		{New, WaitingOnRequester, false},
+		{Closed, Resolved, false},
+		{InProgress, InProgress, false},
+		{store.Status("unknown"), New, false},
+	}
+	for _, tc := range tt {
+		if got := CanTransition(tc.from, tc.to); got != tc.ok {
+			t.Errorf("CanTransition(%s, %s) = %t, expected %t", tc.from, tc.to, got, tc.ok)
+		}
+	}
+}
+
+func TestTransitionRecordsHistory(t *testing.T) {
+	ticket := &store.Ticket{Status: New}
+	if err := Claim(ticket, "U1"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := Resolve(ticket, "U1"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if ticket.Status != Resolved || ticket.Assignee != "U1" {
+		t.Fatalf("Unexpected ticket: %+v", ticket)
+	}
+	if len(ticket.History) != 2 {
+		t.Fatalf("Expected 2 transitions to be recorded. Got %d", len(ticket.History))
+	}
+	h := ticket.History[1]
+	if h.From != InProgress || h.To != Resolved || h.Actor != "U1" || h.At.IsZero() {
+		t.Fatalf("Unexpected transition: %+v", h)
+	}
+}
+
+func TestInvalidTransition(t *testing.T) {
+	ticket := &store.Ticket{Status: Closed}
+	err := Resolve(ticket, "U1")
+	te, ok := err.(*TransitionError)
+	if !ok {
+		t.Fatalf("Expected a *TransitionError. Got '%v'", err)
+	}
+	if te.Error() != "can not move ticket from closed to resolved" {
+		t.Fatalf("Unexpected error: %s", te)
+	}
+	if ticket.Status != Closed || len(ticket.History) != 0 {
+		t.Fatalf("A failed transition should not change the ticket: %+v", ticket)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	unassigned := &store.Ticket{Status: Resolved}
+	if err := Reopen(unassigned, "U1"); err != nil || unassigned.Status != New {
+		t.Fatalf("Expected an unassigned ticket to be reopened as new. Got %s (%v)", unassigned.Status, err)
+	}
+	assigned := &store.Ticket{Status: Closed, Assignee: "U2"}
+	if err := Reopen(assigned, "U1"); err != nil || assigned.Status != InProgress {
+		t.Fatalf("Expected an assigned ticket to be reopened in progress. Got %s (%v)", assigned.Status, err)
+	}
+	open := &store.Ticket{Status: Resolved, Assignee: "U2"}
+	Reopen(open, "U1")
+	if err := Reopen(open, "U1"); err == nil {
+		t.Fatal("Expected an open ticket not to be reopened")
+	}
+}
+
+func TestAssign(t *testing.T) {
+	tk := &store.Ticket{Status: New}
+	if err := Assign("U2")(tk, "U1"); err != nil || tk.Status != InProgress || tk.Assignee != "U2" {
+		t.Fatalf("Expected a new ticket to be assigned and in progress. Got %+v (%v)", tk, err)
+	}
+	if err := Assign("U3")(tk, "U1"); err != nil || tk.Assignee != "U3" || len(tk.History) != 1 {
+		t.Fatalf("Expected a ticket in progress to be reassigned. Got %+v (%v)", tk, err)
+	}
+	if err := Assign("U2")(&store.Ticket{Status: Resolved}, "U1"); err == nil {
+		t.Fatal("Expected a resolved ticket not to be assigned")
+	}
+}
+
+func TestApply(t *testing.T) {
+	ts := store.NewMemory("HD")
+	ticket := &store.Ticket{Status: New}
+	ts.Create(ticket)
+
+	if _, err := Apply(ts, ticket.ID, "U1", Claim); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stored, _ := ts.Get(ticket.ID)
+	if stored.Status != InProgress || stored.Assignee != "U1" || len(stored.History) != 1 {
+		t.Fatalf("Expected the transition to be saved: %+v", stored)
+	}
+	if _, err := Apply(ts, ticket.ID, "U1", Claim); err == nil {
+		t.Fatal("Expected claiming an in progress ticket to fail")
+	}
+	if _, err := Apply(ts, "HD-99", "U1", Claim); err != store.ErrNotFound {
+		t.Fatalf("Expected ErrNotFound. Got '%v'", err)
+	}
+}
+
+func TestConcurrentApply(t *testing.T) {
+	ts := store.NewMemory("HD")
+	ticket := &store.Ticket{Status: New}
+	ts.Create(ticket)
+
+	// Both clicks see a new ticket, only one may claim it
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, actor := range []string{"U1", "U2"} {
+		wg.Add(1)
+		go func(actor string) {
+			defer wg.Done()
+			_, err := Apply(ts, ticket.ID, actor, Claim)
+			errs <- err
+		}(actor)
+	}
+	wg.Wait()
+	close(errs)
+	failed := 0
+	for err := range errs {
+		if _, ok := err.(*TransitionError); ok {
+			failed++
+		}
+	}
+	stored, _ := ts.Get(ticket.ID)
+	if failed != 1 || len(stored.History) != 1 {
+		t.Fatalf("Expected exactly one claim to succeed. Got %d failures and %+v", failed, stored.History)
+	}
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/skybet/go-helpdesk/handlers"
 	"github.com/skybet/go-helpdesk/server"
+	"github.com/skybet/go-helpdesk/store"
 	"github.com/skybet/go-helpdesk/wrapper"
 
 	log "github.com/sirupsen/logrus"
@@ -19,6 +21,8 @@ import (
 
 // Generate mocks - go get github.com/vektra/mockery/.../ first
 //go:generate mockery -name SlackWrapper -recursive
+//go:generate mockery -name JiraWrapper -recursive
+//go:generate mockery -name PagerDutyWrapper -recursive
 
 func main() {
 	initFlags()
@@ -26,7 +30,9 @@ func main() {
 	appToken := viper.GetString("app-token")
 	botToken := viper.GetString("bot-token")
 	signingSecret := viper.GetString("signing-secret")
-	if appToken == "" || botToken == "" || signingSecret == "" {
+	signingSecretsFile := viper.GetString("signing-secrets-file")
+	socketModeToken := viper.GetString("socket-mode-token")
+	if appToken == "" || botToken == "" || (signingSecret == "" && signingSecretsFile == "" && socketModeToken == "") {
 		pflag.PrintDefaults()
 		return
 	}
@@ -36,17 +42,92 @@ func main() {
 	}
 	handlers.Init(sw)
 	log.Info("Connected to Slack API")
+	// Record help requests so they can be tracked
+	ts, err := store.NewBolt(viper.GetString("store-path"), viper.GetString("ticket-prefix"))
+	if err != nil {
+		log.Fatalf("Error opening the ticket store: %s", err)
+	}
+	defer ts.Close()
+	handlers.InitStore(ts, viper.GetString("ticket-channel"))
+	// Raise help requests in JIRA if it has been configured
+	helpCallback := handlers.HelpCallback
+	var helpOpts []server.RouteOption
+	if jiraURL := viper.GetString("jira-url"); jiraURL != "" {
+		jw, err := wrapper.NewJira(jiraURL, viper.GetString("jira-user"), viper.GetString("jira-token"))
+		if err != nil {
+			log.Fatalf("Error initialising the JIRA API: %s", err)
+		}
+		handlers.InitJira(jw, handlers.JiraConfig{
+			Project:       viper.GetString("jira-project"),
+			IssueType:     viper.GetString("jira-issue-type"),
+			Labels:        viper.GetStringSlice("jira-labels"),
+			FieldMappings: fieldMappings(viper.GetStringSlice("jira-field-mappings")),
+		})
+		helpCallback = handlers.JiraHelpCallback
+		// Raising the issue can take longer than Slack waits for a modal submission
+		helpOpts = append(helpOpts, server.Async())
+		log.Info("Connected to JIRA API")
+	}
 	// Start a server to respond to callbacks from Slack
-	s := server.NewSlackHandler("/slack", appToken, signingSecret, nil, log.Info, log.Infof, log.Error, log.Errorf)
-	s.HandleCommand("/help-me", handlers.HelpRequest)
-	s.HandleInteractionCallback("dialog_submission", "HelpRequest", handlers.HelpCallback)
-	addr := viper.GetString("listen-address")
-	go func() {
-		if err := http.ListenAndServe(addr, s); err != nil {
-			log.Fatalf("Unable to start server: %s", err)
+	// Signing secrets read from a file can be rotated without a restart
+	secrets := server.WithSigningSecret(signingSecret)
+	if signingSecretsFile != "" {
+		secrets = server.WithSecretProvider(server.NewFileSecrets(signingSecretsFile))
+	}
+	opts := []server.Option{
+		server.WithAppToken(appToken),
+		secrets,
+		server.WithLogger(server.NewLogrusLogger(log.StandardLogger())),
+		server.WithMaxBodySize(viper.GetInt64("max-body-size")),
+	}
+	// Verify Slack's client certificate when terminating TLS ourselves, or trust a proxy's DN header
+	clientCA := viper.GetString("tls-client-ca")
+	if clientCA != "" {
+		opts = append(opts, server.WithMutualTLS(viper.GetStringSlice("tls-client-names")...))
+	} else if header := viper.GetString("mtls-header"); header != "" {
+		opts = append(opts, server.WithMutualTLSHeader(header))
+	}
+	s := server.New(opts...)
+	if err := registerRoutes(s, helpCallback, helpOpts...); err != nil {
+		log.Fatalf("Unable to register routes: %s", err)
+	}
+	log.Infof("Registered routes:\n%s", s.RouteTable())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if socketModeToken != "" {
+		// Receive requests over a WebSocket, no public endpoint is required
+		sm := server.NewSocketMode(s, socketModeToken)
+		go func() {
+			if err := sm.Run(ctx); err != nil {
+				log.Fatalf("Unable to connect in Socket Mode: %s", err)
+			}
+		}()
+		log.Info("Receiving Slack requests over Socket Mode")
+	} else {
+		srv := &http.Server{Addr: viper.GetString("listen-address"), Handler: s}
+		if clientCA != "" {
+			if viper.GetString("tls-cert") == "" {
+				log.Fatal("Mutual TLS requires --tls-cert and --tls-key")
+			}
+			cfg, err := server.MutualTLSConfig(clientCA)
+			if err != nil {
+				log.Fatalf("Error configuring mutual TLS: %s", err)
+			}
+			srv.TLSConfig = cfg
 		}
-	}()
-	log.Infof("Listening for Slack callbacks on '%s'", addr)
+		go func() {
+			var err error
+			if cert := viper.GetString("tls-cert"); cert != "" {
+				err = srv.ListenAndServeTLS(cert, viper.GetString("tls-key"))
+			} else {
+				err = srv.ListenAndServe()
+			}
+			if err != nil {
+				log.Fatalf("Unable to start server: %s", err)
+			}
+		}()
+		log.Infof("Listening for Slack callbacks on '%s'", srv.Addr)
+	}
 	terminate := make(chan os.Signal, 1)
 	signal.Notify(terminate, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL)
 	<-terminate
@@ -56,8 +137,27 @@ func initFlags() {
 	// Bind flags
 	pflag.StringP("app-token", "a", "", "Slack API token for your slash command (required)")
 	pflag.StringP("bot-token", "b", "", "Slack API token for bot integration (required)")
-	pflag.StringP("signing-secret", "s", "", "Slack API signing secret for request verification (required)")
+	pflag.StringP("signing-secret", "s", "", "Slack API signing secret for request verification (required unless using Socket Mode)")
+	pflag.String("signing-secrets-file", "", "File of Slack API signing secrets, one per line, used instead of --signing-secret. Reloaded when changed")
 	pflag.StringP("listen-address", "l", ":4390", "Address to listen for Slack callbacks on")
+	pflag.Int64("max-body-size", 1<<20, "Largest request body accepted from Slack in bytes, 0 for no limit")
+	pflag.String("tls-cert", "", "TLS certificate to serve Slack callbacks with, HTTPS is used when set")
+	pflag.String("tls-key", "", "Private key for --tls-cert")
+	pflag.String("tls-client-ca", "", "CA bundle which Slack's client certificate must be signed by, enables mutual TLS. Requires --tls-cert")
+	pflag.StringSlice("tls-client-names", []string{server.SlackClientName}, "Names accepted in the subject or SANs of the client certificate")
+	pflag.String("mtls-header", "", "Header containing the DN of the client certificate, when mutual TLS is terminated by a proxy")
+	pflag.String("socket-mode-token", "", "Slack app-level token, requests are received over Socket Mode instead of HTTP when set")
+	pflag.String("store-path", "helpdesk.db", "Path of the database help requests are recorded in")
+	pflag.String("ticket-prefix", "HD", "Prefix for the IDs of help request tickets")
+	pflag.String("ticket-channel", "", "Channel to post tickets to, defaults to the channel help was requested from")
+	pflag.String("jira-url", "", "Base URL of JIRA, help requests are raised as issues when set")
+	pflag.String("jira-user", "", "JIRA username")
+	pflag.String("jira-token", "", "JIRA API token")
+	pflag.String("jira-project", "HELP", "JIRA project key to raise help requests in")
+	pflag.String("jira-issue-type", "Task", "JIRA issue type to raise help requests as")
+	pflag.StringSlice("jira-labels", nil, "Labels to add to JIRA issues")
+	pflag.StringSlice("jira-field-mappings", nil, "Help request fields to map to JIRA fields, e.g. urgency=customfield_10010")
+	pflag.String("pagerduty-routing-key", "", "PagerDuty Events API v2 routing key, enables the /page command when set")
 	pflag.Parse()
 	viper.BindPFlags(pflag.CommandLine)
 	// Allow setting flags from environment variables
@@ -68,3 +168,54 @@ func initFlags() {
 	replacer := strings.NewReplacer("-", "_")
 	viper.SetEnvKeyReplacer(replacer)
 }
+
+// fieldMappings parses field=jiraField pairs into a map
+func fieldMappings(pairs []string) map[string]string {
+	m := map[string]string{}
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			log.Fatalf("Invalid JIRA field mapping: %s", p)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+// registerRoutes adds the help desk commands, interactions and actions to the server
+// The options are applied to the route for help request submissions
+func registerRoutes(s *server.SlackHandler, helpCallback server.InteractionHandlerFunc, helpOpts ...server.RouteOption) error {
+	helpMe, err := s.HandleSubcommands("/help-me",
+		server.WithDescription("Open a form to ask the help desk for help, or manage tickets with the commands below"))
+	if err != nil {
+		return err
+	}
+	helpMe.Default = handlers.HelpRequest
+	if err := handlers.RegisterTicketCommands(helpMe); err != nil {
+		return err
+	}
+	errs := []error{
+		s.HandleViewSubmissionFunc("HelpRequest", helpCallback, helpOpts...),
+		s.Hears(`\S`, handlers.HelpMention, server.Mentioned(), server.InThreads(server.IgnoreThreads)),
+		s.HandleBlockActionFunc(handlers.TicketClaimAction, handlers.TicketClaim, server.Async()),
+		s.HandleBlockActionFunc(handlers.TicketResolveAction, handlers.TicketResolve, server.Async()),
+		s.HandleBlockActionFunc(handlers.TicketReopenAction, handlers.TicketReopen, server.Async()),
+	}
+	// Page on-call through PagerDuty if it has been configured
+	if routingKey := viper.GetString("pagerduty-routing-key"); routingKey != "" {
+		handlers.InitPagerDuty(wrapper.NewPagerDuty(routingKey))
+		errs = append(errs,
+			s.HandleCommandFunc("/page", handlers.Page, server.Async(),
+				server.WithDescription("Page whoever is on-call"), server.WithUsage("<description of the problem>"),
+				server.WithExamples("/page The VPN is down for everyone")),
+			s.HandleBlockActionFunc(handlers.PageAcknowledgeAction, handlers.PageAcknowledge, server.Async()),
+			s.HandleBlockActionFunc(handlers.PageResolveAction, handlers.PageResolve, server.Async()),
+		)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,13 +2,28 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
-import slack "github.com/nlopes/slack"
+import slack "github.com/slack-go/slack"
+import wrapper "github.com/skybet/go-helpdesk/wrapper"
 
 // SlackWrapper is an autogenerated mock type for the SlackWrapper type
 type SlackWrapper struct {
 	mock.Mock
 }
 
+// DeleteMessage provides a mock function with given fields: ref
+func (_m *SlackWrapper) DeleteMessage(ref wrapper.MessageRef) error {
+	ret := _m.Called(ref)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(wrapper.MessageRef) error); ok {
+		r0 = rf(ref)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // OpenDialog provides a mock function with given fields: triggerID, dialog
 func (_m *SlackWrapper) OpenDialog(triggerID string, dialog slack.Dialog) error {
 	ret := _m.Called(triggerID, dialog)
@@ -23,7 +38,171 @@ func (_m *SlackWrapper) OpenDialog(triggerID string, dialog slack.Dialog) error
 	return r0
 }
 
-// SendMessage provides a mock function with given fields: message, channel
-func (_m *SlackWrapper) SendMessage(message string, channel string) {
-	_m.Called(message, channel)
+// OpenView provides a mock function with given fields: triggerID, view
+func (_m *SlackWrapper) OpenView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	ret := _m.Called(triggerID, view)
+
+	var r0 *slack.ViewResponse
+	if rf, ok := ret.Get(0).(func(string, slack.ModalViewRequest) *slack.ViewResponse); ok {
+		r0 = rf(triggerID, view)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*slack.ViewResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, slack.ModalViewRequest) error); ok {
+		r1 = rf(triggerID, view)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostEphemeral provides a mock function with given fields: channelID, userID, msg
+func (_m *SlackWrapper) PostEphemeral(channelID string, userID string, msg wrapper.Message) error {
+	ret := _m.Called(channelID, userID, msg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, wrapper.Message) error); ok {
+		r0 = rf(channelID, userID, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PostMessage provides a mock function with given fields: channelID, msg
+func (_m *SlackWrapper) PostMessage(channelID string, msg wrapper.Message) (wrapper.MessageRef, error) {
+	ret := _m.Called(channelID, msg)
+
+	var r0 wrapper.MessageRef
+	if rf, ok := ret.Get(0).(func(string, wrapper.Message) wrapper.MessageRef); ok {
+		r0 = rf(channelID, msg)
+	} else {
+		r0 = ret.Get(0).(wrapper.MessageRef)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, wrapper.Message) error); ok {
+		r1 = rf(channelID, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostThreadReply provides a mock function with given fields: parent, msg
+func (_m *SlackWrapper) PostThreadReply(parent wrapper.MessageRef, msg wrapper.Message) (wrapper.MessageRef, error) {
+	ret := _m.Called(parent, msg)
+
+	var r0 wrapper.MessageRef
+	if rf, ok := ret.Get(0).(func(wrapper.MessageRef, wrapper.Message) wrapper.MessageRef); ok {
+		r0 = rf(parent, msg)
+	} else {
+		r0 = ret.Get(0).(wrapper.MessageRef)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(wrapper.MessageRef, wrapper.Message) error); ok {
+		r1 = rf(parent, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublishHomeView provides a mock function with given fields: userID, view
+func (_m *SlackWrapper) PublishHomeView(userID string, view slack.HomeTabViewRequest) (*slack.ViewResponse, error) {
+	ret := _m.Called(userID, view)
+
+	var r0 *slack.ViewResponse
+	if rf, ok := ret.Get(0).(func(string, slack.HomeTabViewRequest) *slack.ViewResponse); ok {
+		r0 = rf(userID, view)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*slack.ViewResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, slack.HomeTabViewRequest) error); ok {
+		r1 = rf(userID, view)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PushView provides a mock function with given fields: triggerID, view
+func (_m *SlackWrapper) PushView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	ret := _m.Called(triggerID, view)
+
+	var r0 *slack.ViewResponse
+	if rf, ok := ret.Get(0).(func(string, slack.ModalViewRequest) *slack.ViewResponse); ok {
+		r0 = rf(triggerID, view)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*slack.ViewResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, slack.ModalViewRequest) error); ok {
+		r1 = rf(triggerID, view)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMessage provides a mock function with given fields: ref, msg
+func (_m *SlackWrapper) UpdateMessage(ref wrapper.MessageRef, msg wrapper.Message) (wrapper.MessageRef, error) {
+	ret := _m.Called(ref, msg)
+
+	var r0 wrapper.MessageRef
+	if rf, ok := ret.Get(0).(func(wrapper.MessageRef, wrapper.Message) wrapper.MessageRef); ok {
+		r0 = rf(ref, msg)
+	} else {
+		r0 = ret.Get(0).(wrapper.MessageRef)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(wrapper.MessageRef, wrapper.Message) error); ok {
+		r1 = rf(ref, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateView provides a mock function with given fields: viewID, hash, view
+func (_m *SlackWrapper) UpdateView(viewID string, hash string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	ret := _m.Called(viewID, hash, view)
+
+	var r0 *slack.ViewResponse
+	if rf, ok := ret.Get(0).(func(string, string, slack.ModalViewRequest) *slack.ViewResponse); ok {
+		r0 = rf(viewID, hash, view)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*slack.ViewResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, slack.ModalViewRequest) error); ok {
+		r1 = rf(viewID, hash, view)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// DefaultAsyncWorkers is the number of workers used to execute Async routes
+// when SlackHandler.AsyncWorkers is not set
+const DefaultAsyncWorkers = 10
+
+// responseClient is used to post delayed responses to a Slack response_url
+var responseClient = &http.Client{Timeout: 10 * time.Second}
+
+// Async marks a route as slow running. Slack is sent an empty 200 immediately and the
+// handler is executed on a bounded worker pool. As the HTTP response has already been
+// sent, handlers should use Request.PostResponse to send their eventual result to Slack
+func Async() RouteOption {
+	return func(r *Route) {
+		r.Async = true
+	}
+}
+
+// workerPool executes jobs on a fixed number of goroutines
+type workerPool struct {
+	jobs chan func()
+}
+
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{jobs: make(chan func(), workers)}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues a job and reports false if the pool is saturated
+func (p *workerPool) submit(job func()) bool {
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// discardResponseWriter is handed to Async handlers, which run after Slack has been acknowledged
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	if d.header == nil {
+		d.header = http.Header{}
+	}
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (d *discardResponseWriter) WriteHeader(int) {}
+
+// serveAsync acknowledges the request and queues the handler chain for rt on the worker pool
+func (h *SlackHandler) serveAsync(rt *Route, res *Response, req *Request, ctx interface{}) {
+	h.poolOnce.Do(func() {
+		workers := h.AsyncWorkers
+		if workers <= 0 {
+			workers = DefaultAsyncWorkers
+		}
+		h.pool = newWorkerPool(workers)
+	})
+
+	// The original request is cancelled as soon as ServeHTTP returns, so detach it
+	// from the request context while keeping the request scoped values
+	detached := &Request{Request: req.Request.Clone(detach(req.Context())), payload: req.payload, body: req.body}
+	f := h.handlerFor(rt)
+	ok := h.pool.submit(func() {
+		start := time.Now()
+		err := call(f, &Response{&discardResponseWriter{}}, detached, ctx)
+		h.metrics().Handled(rt, time.Since(start), err)
+		if err != nil {
+			h.logError(detached, "Async handler error: %s", err)
+			h.respondError(res, detached, ctx, true, err)
+		}
+	})
+	if !ok {
+		h.metrics().Rejected(RejectedSaturated)
+		LoggerFromContext(req.Context()).Error(fmt.Sprintf("Async worker pool is saturated, rejecting request to %s", req.URL.Path), nil)
+		res.Text(http.StatusServiceUnavailable, "Too many requests in progress")
+		return
+	}
+	res.WriteHeader(http.StatusOK)
+}
+
+// ResponseURL returns the response_url sent by Slack with a slash command or interaction
+func (r *Request) ResponseURL() string {
+	if r.payload != nil {
+		return r.payload.ResponseURL
+	}
+	return r.FormValue("response_url")
+}
+
+// PostResponse sends msg to the response_url of the request
+func (r *Request) PostResponse(msg *slack.Msg) error {
+	u := r.ResponseURL()
+	if u == "" {
+		return fmt.Errorf("request has no response_url")
+	}
+	return PostResponse(u, msg)
+}
+
+// PostResponse sends msg to a Slack response_url
+func PostResponse(responseURL string, msg *slack.Msg) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error encoding response: %s", err)
+	}
+	resp, err := responseClient.Post(responseURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("error posting response: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status posting response: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func slashCommandWithResponseURL(responseURL string) string {
+	v := url.Values{}
+	v.Set("token", "TOKEN")
+	v.Set("team_id", "T01ABC")
+	v.Set("user_id", "UABC123")
+	v.Set("command", "/bob-test")
+	v.Set("response_url", responseURL)
+	v.Set("trigger_id", "400003447986.4709815545.5c0291e01b37fc97ab64d8d7888f6cda")
+	return v.Encode()
+}
+
+func TestAsyncRoute(t *testing.T) {
+	posted := make(chan slack.Msg, 1)
+	slackStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Msg
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Unable to decode delayed response: %s", err)
+		}
+		posted <- msg
+	}))
+	defer slackStub.Close()
+
+	release := make(chan struct{})
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		<-release
+		if err := req.Context().Err(); err != nil {
+			t.Errorf("Expected a detached context. Got '%s'", err)
+		}
+		return req.PostResponse(&slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: "All done"})
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h, Async())
+	resp := performGenericFormRequest(slashCommandWithResponseURL(slackStub.URL), basePath, s)
+
+	// The handler is blocked, so this proves Slack was acknowledged first
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	close(release)
+
+	select {
+	case msg := <-posted:
+		if msg.Text != "All done" || msg.ResponseType != slack.ResponseTypeEphemeral {
+			t.Fatalf("Unexpected delayed response: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the delayed response")
+	}
+}
+
+func TestAsyncPoolSaturated(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	defer close(release)
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.AsyncWorkers = 1
+	s.HandleCommand("/bob-test", h, Async())
+
+	// The first request occupies the only worker
+	if resp := performGenericFormRequest(slashCommandRaw, basePath, s); resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	<-started
+	// The second request fills the queue
+	if resp := performGenericFormRequest(slashCommandRaw, basePath, s); resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	// The third has nowhere to go
+	if resp := performGenericFormRequest(slashCommandRaw, basePath, s); resp.StatusCode != 503 {
+		t.Fatalf("Expected a 503 status. Got '%d'", resp.StatusCode)
+	}
+	if logString != "Async worker pool is saturated, rejecting request to /slack" {
+		t.Fatalf("Unexpected error string: %s", logString)
+	}
+}
+
+func TestPostResponseWithoutURL(t *testing.T) {
+	req := &Request{Request: httptest.NewRequest("POST", "/slack", nil)}
+	if err := req.PostResponse(&slack.Msg{Text: "hello"}); err == nil {
+		t.Fatal("Expected an error when the request has no response_url")
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"github.com/slack-go/slack"
+)
+
+// BlockActionCallback is passed as context to handlers registered with HandleBlockAction
+type BlockActionCallback struct {
+	*slack.InteractionCallback
+	// Action is the block action which matched the route
+	Action *slack.BlockAction
+}
+
+// ForBlock restricts a block action route to actions from a specific block
+func ForBlock(blockID string) RouteOption {
+	return func(r *Route) {
+		r.BlockID = blockID
+	}
+}
+
+// HandleBlockAction registers a handler to be executed when a block_actions
+// interaction contains an action with the given ActionID
+// The handler is passed a *BlockActionCallback as context
+func (h *SlackHandler) HandleBlockAction(actionID string, f SlackHandlerFunc, opts ...RouteOption) error {
+	r := &Route{Path: h.basePath, InteractionType: string(slack.InteractionTypeBlockActions), ActionID: actionID, Handler: f}
+	return h.handle(r, opts)
+}
+
+// HandleViewSubmission registers a handler to be executed when a modal with the
+// given CallbackID is submitted. The handler is passed a *slack.InteractionCallback as context
+func (h *SlackHandler) HandleViewSubmission(cid string, f SlackHandlerFunc, opts ...RouteOption) error {
+	return h.HandleInteractionCallback(string(slack.InteractionTypeViewSubmission), cid, f, opts...)
+}
+
+// HandleViewClosed registers a handler to be executed when a modal with the
+// given CallbackID is closed. The modal must have been opened with NotifyOnClose set
+func (h *SlackHandler) HandleViewClosed(cid string, f SlackHandlerFunc, opts ...RouteOption) error {
+	return h.HandleInteractionCallback(string(slack.InteractionTypeViewClosed), cid, f, opts...)
+}
+
+// HandleShortcut registers a handler to be executed when the global shortcut
+// with the given CallbackID is used
+func (h *SlackHandler) HandleShortcut(cid string, f SlackHandlerFunc, opts ...RouteOption) error {
+	return h.HandleInteractionCallback(string(slack.InteractionTypeShortcut), cid, f, opts...)
+}
+
+// HandleMessageShortcut registers a handler to be executed when the message
+// shortcut with the given CallbackID is used
+func (h *SlackHandler) HandleMessageShortcut(cid string, f SlackHandlerFunc, opts ...RouteOption) error {
+	return h.HandleInteractionCallback(string(slack.InteractionTypeMessageAction), cid, f, opts...)
+}
+
+// interactionCallbackID returns the CallbackID which identifies an interaction
+// Modal interactions carry their CallbackID on the view rather than the payload
+func interactionCallbackID(p *slack.InteractionCallback) string {
+	switch p.Type {
+	case slack.InteractionTypeViewSubmission, slack.InteractionTypeViewClosed:
+		return p.View.CallbackID
+	default:
+		return p.CallbackID
+	}
+}
+
+// matchInteraction finds the route for an interaction payload, the context which should be
+// passed to its handler and any parameters captured by its pattern. A nil route is returned if nothing matches
+func (h *SlackHandler) matchInteraction(p *slack.InteractionCallback) (*Route, interface{}, map[string]string) {
+	if p.Type == slack.InteractionTypeBlockActions {
+		for _, a := range p.ActionCallback.BlockActions {
+			var best bestMatch
+			for _, rt := range h.routes {
+				if rt.InteractionType != string(p.Type) || rt.BlockID != "" && rt.BlockID != a.BlockID {
+					continue
+				}
+				best.consider(rt, a.ActionID)
+			}
+			if best.route != nil {
+				return best.route, &BlockActionCallback{InteractionCallback: p, Action: a}, best.params
+			}
+		}
+		return nil, nil, nil
+	}
+
+	var best bestMatch
+	for _, rt := range h.routes {
+		if string(p.Type) == rt.InteractionType {
+			best.consider(rt, interactionCallbackID(p))
+		}
+	}
+	return best.route, p, best.params
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func interactionRaw(payload string) string {
+	return url.Values{"payload": {payload}}.Encode()
+}
+
+func TestBlockAction(t *testing.T) {
+	raw := interactionRaw(`{"type":"block_actions","team":{"id":"T1ABCD2E12"},"user":{"id":"W12A3BCDEF","name":"dreamweaver"},"trigger_id":"12345.98765.abcd2358fdea","response_url":"https://hooks.slack.com/actions/T1ABCD2E12/1234/abcd","actions":[{"type":"button","action_id":"claim","block_id":"ticket","value":"1234","action_ts":"1548426417.840180"}]}`)
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		b, ok := ctx.(*BlockActionCallback)
+		if !ok {
+			t.Fatalf("Expected a *BlockActionCallback to be passed to the handler")
+		}
+		if b.Action.Value != "1234" {
+			t.Fatalf("Unexpected value for Action.Value: %s", b.Action.Value)
+		}
+		if b.User.ID != "W12A3BCDEF" {
+			t.Fatalf("Unexpected value for User.ID: %s", b.User.ID)
+		}
+		res.Text(202, "claimed")
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleBlockAction("claim", h)
+	resp := performGenericFormRequest(raw, basePath, s)
+
+	if resp.StatusCode != 202 {
+		t.Logf("ErrString: %s", logString)
+		t.Fatalf("Expected a 202 status. Got '%d'", resp.StatusCode)
+	}
+}
+
+func TestBlockActionForBlock(t *testing.T) {
+	raw := interactionRaw(`{"type":"block_actions","user":{"id":"W12A3BCDEF"},"actions":[{"type":"button","action_id":"claim","block_id":"ticket","value":"1234"}]}`)
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		t.Fatalf("Handler for a different block should not have been executed")
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleBlockAction("claim", h, ForBlock("other"))
+	resp := performGenericFormRequest(raw, basePath, s)
+
+	if resp.StatusCode != 404 {
+		t.Fatalf("Expected a 404 status. Got '%d'", resp.StatusCode)
+	}
+}
+
+func TestViewSubmission(t *testing.T) {
+	raw := interactionRaw(`{"type":"view_submission","team":{"id":"T1ABCD2E12"},"user":{"id":"W12A3BCDEF"},"view":{"id":"VNHU13V36","type":"modal","callback_id":"HelpRequest","private_metadata":"C1AB2C3DE","state":{"values":{"description":{"value":{"type":"plain_text_input","value":"My VPN is down"}}}}}}`)
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		ic, ok := ctx.(*slack.InteractionCallback)
+		if !ok {
+			t.Fatalf("Expected a *slack.InteractionCallback to be passed to the handler")
+		}
+		if v := ic.View.State.Values["description"]["value"].Value; v != "My VPN is down" {
+			t.Fatalf("Unexpected submitted value: %s", v)
+		}
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleViewSubmission("HelpRequest", h)
+	resp := performGenericFormRequest(raw, basePath, s)
+
+	if resp.StatusCode != 200 {
+		t.Logf("ErrString: %s", logString)
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+}
+
+func TestViewClosedAndShortcuts(t *testing.T) {
+	tt := []struct {
+		name     string
+		payload  string
+		register func(s *SlackHandler, f SlackHandlerFunc)
+	}{
+		{
+			"View closed",
+			`{"type":"view_closed","user":{"id":"W12A3BCDEF"},"view":{"callback_id":"HelpRequest"}}`,
+			func(s *SlackHandler, f SlackHandlerFunc) { s.HandleViewClosed("HelpRequest", f) },
+		},
+		{
+			"Global shortcut",
+			`{"type":"shortcut","callback_id":"raise_ticket","trigger_id":"12345.98765","user":{"id":"W12A3BCDEF"}}`,
+			func(s *SlackHandler, f SlackHandlerFunc) { s.HandleShortcut("raise_ticket", f) },
+		},
+		{
+			"Message shortcut",
+			`{"type":"message_action","callback_id":"raise_from_message","trigger_id":"12345.98765","user":{"id":"W12A3BCDEF"},"message":{"text":"help!"}}`,
+			func(s *SlackHandler, f SlackHandlerFunc) { s.HandleMessageShortcut("raise_from_message", f) },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := func(res *Response, req *Request, ctx interface{}) error {
+				if _, ok := ctx.(*slack.InteractionCallback); !ok {
+					t.Fatalf("Expected a *slack.InteractionCallback to be passed to the handler")
+				}
+				called = true
+				return nil
+			}
+			s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+			tc.register(s, h)
+			resp := performGenericFormRequest(interactionRaw(tc.payload), basePath, s)
+			if resp.StatusCode != 200 || !called {
+				t.Fatalf("Expected the handler to be called with a 200 status. Got '%d'", resp.StatusCode)
+			}
+		})
+	}
+}
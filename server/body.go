@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+)
+
+// ErrBodyTooLarge is returned when a request body is larger than the SlackHandler.MaxBodySize
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// DefaultRedactedFields are removed from request bodies before they are logged. The token is
+// the app's verification token and a response_url lets anybody reply to the user
+var DefaultRedactedFields = []string{"token", "response_url"}
+
+// Redactor removes anything sensitive from a request body before it is logged
+type Redactor func(body []byte) []byte
+
+// RedactFields returns a Redactor which replaces the values of the named form fields or JSON
+// object keys, including those in a form field holding JSON such as the payload of an interaction
+func RedactFields(names ...string) Redactor {
+	redact := map[string]bool{}
+	for _, n := range names {
+		redact[n] = true
+	}
+	return func(body []byte) []byte {
+		if b, ok := redactJSON(body, redact); ok {
+			return b
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte("[unparsable body redacted]")
+		}
+		for k, v := range form {
+			if redact[k] {
+				form[k] = []string{"REDACTED"}
+			} else if b, ok := redactJSON([]byte(v[0]), redact); ok {
+				form[k] = []string{string(b)}
+			}
+		}
+		// Unescape so that an interaction payload is readable
+		b, err := url.QueryUnescape(form.Encode())
+		if err != nil {
+			return []byte(form.Encode())
+		}
+		return []byte(b)
+	}
+}
+
+// redactJSON replaces the values of the named keys at any depth in a JSON object
+func redactJSON(body []byte, redact map[string]bool) ([]byte, bool) {
+	var v map[string]interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, false
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, c := range t {
+				if redact[k] {
+					t[k] = "REDACTED"
+				} else {
+					walk(c)
+				}
+			}
+		case []interface{}:
+			for _, c := range t {
+				walk(c)
+			}
+		}
+	}
+	walk(v)
+	b, err := json.Marshal(v)
+	return b, err == nil
+}
+
+// RawBody returns the body of the request exactly as it was received. It is read once, so
+// handlers can use it after the request has been validated and parsed
+func (r *Request) RawBody() []byte {
+	b, _ := r.readBody(0)
+	return b
+}
+
+// readBody reads the whole body in to the Request the first time it is called, failing with
+// ErrBodyTooLarge when it is longer than limit, if limit is greater than 0. The http.Request
+// body is replaced so that it can still be read
+func (r *Request) readBody(limit int64) ([]byte, error) {
+	if r.body != nil {
+		return r.body.b, r.body.err
+	}
+	r.body = &requestBody{}
+	if r.Body == nil {
+		return nil, nil
+	}
+	var src io.Reader = r.Body
+	if limit > 0 {
+		src = io.LimitReader(r.Body, limit+1)
+	}
+	b, err := ioutil.ReadAll(src)
+	r.Body.Close()
+	switch {
+	case err != nil:
+		r.body.err = fmt.Errorf("%w: %s", ErrInvalidBody, err)
+	case limit > 0 && int64(len(b)) > limit:
+		r.body.err = fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, limit)
+		b = nil
+	}
+	r.body.b = b
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return r.body.b, r.body.err
+}
+
+// requestBody is the captured body of a Request, shared by its copies
+type requestBody struct {
+	b   []byte
+	err error
+}
+
+// redactedBody returns the request body for logging, with sensitive values removed
+func (h *SlackHandler) redactedBody(req *Request) string {
+	b := req.RawBody()
+	if len(b) == 0 {
+		return ""
+	}
+	if h.RedactBody != nil {
+		b = h.RedactBody(b)
+	}
+	return string(b)
+}
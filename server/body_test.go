@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRawBody(t *testing.T) {
+	var raw, read []byte
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		raw = req.RawBody()
+		read, _ = ioutil.ReadAll(req.Body)
+		return nil
+	})
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+	if string(raw) != slashCommandRaw || string(read) != slashCommandRaw {
+		t.Fatalf("Expected the body to be available to the handler. Got %q and %q", raw, read)
+	}
+}
+
+func TestReadBodyLimit(t *testing.T) {
+	tt := []struct {
+		name  string
+		limit int64
+		err   error
+	}{
+		{"No limit", 0, nil},
+		{"At the limit", 4, nil},
+		{"Over the limit", 3, ErrBodyTooLarge},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Request{Request: httptest.NewRequest("POST", basePath, bytes.NewBufferString("text"))}
+			b, err := r.readBody(tc.limit)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected %v. Got %v", tc.err, err)
+			}
+			if tc.err == nil && string(b) != "text" {
+				t.Fatalf("Unexpected body: %q", b)
+			}
+			// Later reads use the captured body
+			if again, againErr := r.readBody(tc.limit); string(again) != string(b) || againErr != err {
+				t.Fatalf("Expected the body to be read once")
+			}
+		})
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	redact := RedactFields(DefaultRedactedFields...)
+	tt := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			"Form",
+			"command=%2Fbob-test&token=TOKEN&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2F1",
+			"command=/bob-test&response_url=REDACTED&token=REDACTED",
+		},
+		{
+			"Interaction payload",
+			`payload=` + `{"type":"block_actions","token":"TOKEN","response_url":"https://hooks.slack.com/actions/1"}`,
+			`payload={"response_url":"REDACTED","token":"REDACTED","type":"block_actions"}`,
+		},
+		{
+			"Event",
+			`{"type":"event_callback","token":"TOKEN","event":{"type":"message","items":[{"token":"TOKEN"}]}}`,
+			`{"event":{"items":[{"token":"REDACTED"}],"type":"message"},"token":"REDACTED","type":"event_callback"}`,
+		},
+		{"Unparsable", "%zz", "[unparsable body redacted]"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(redact([]byte(tc.body))); got != tc.want {
+				t.Fatalf("Unexpected redacted body: %s", got)
+			}
+		})
+	}
+}
+
+func TestErrorLogsRedactedBody(t *testing.T) {
+	tt := []struct {
+		name     string
+		redactor Redactor
+		want     string
+	}{
+		{"Default", RedactFields(DefaultRedactedFields...), "token=REDACTED"},
+		{"Custom", func(b []byte) []byte { return []byte("hidden") }, "body=hidden"},
+		{"Disabled", nil, "token=TOKEN"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newRecordingLogger()
+			s := New(WithSigningSecret(slackSecret), WithLogger(l), WithBodyRedactor(tc.redactor))
+			s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+				return fmt.Errorf("failed")
+			})
+			req := httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString(slashCommandRaw))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			addSlackHeaders(slashCommandRaw, req)
+			performGenericRequest(req, s)
+			entries := *l.entries
+			last := entries[len(entries)-1]
+			if !strings.HasPrefix(last, "ERROR HTTP handler error: failed body=") || !strings.Contains(last, tc.want) {
+				t.Fatalf("Unexpected error entry: %s", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultResponseTimeout is how long Slack waits for a response before showing the user an
+// error. Synchronous handlers have a deadline this long after the request was received
+const DefaultResponseTimeout = 3 * time.Second
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	routeKey
+	payloadKey
+	paramsKey
+	loggerKey
+	clientIdentityKey
+)
+
+// ContextHandlerFunc is a handler which receives a context.Context rather than the routing
+// context. The context is cancelled when Slack stops waiting for a response and carries
+// the request ID, matched route, parsed payload and route parameters (see RequestID, RouteFromContext,
+// Payload and RouteParams)
+type ContextHandlerFunc func(ctx context.Context, res *Response, req *Request) error
+
+// ContextHandler adapts a ContextHandlerFunc so that it can be registered as a SlackHandlerFunc
+// The payload passed down by any middleware is made available through Payload
+func ContextHandler(f ContextHandlerFunc) SlackHandlerFunc {
+	return func(res *Response, req *Request, payload interface{}) error {
+		ctx := context.WithValue(req.Context(), payloadKey, payload)
+		return f(ctx, res, req.withContext(ctx))
+	}
+}
+
+// RequestID returns the ID assigned to the request being handled
+// Requests received over Socket Mode use the envelope ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// RouteFromContext returns the route which matched the request being handled
+func RouteFromContext(ctx context.Context) *Route {
+	rt, _ := ctx.Value(routeKey).(*Route)
+	return rt
+}
+
+// Payload returns the parsed payload of the request being handled, this is the same value
+// which is passed to a SlackHandlerFunc as its context
+func Payload(ctx context.Context) interface{} {
+	return ctx.Value(payloadKey)
+}
+
+// withRequestID assigns an ID to the request unless it already has one
+func withRequestID(ctx context.Context, id string) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+	if id == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// withContext returns a shallow copy of r with its context changed to ctx
+func (r *Request) withContext(ctx context.Context) *Request {
+	return &Request{Request: r.Request.WithContext(ctx), payload: r.payload, body: r.body}
+}
+
+// detachedContext keeps the values of a request context but not its cancellation or deadline
+type detachedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.values.Value(key)
+}
+
+// detach returns a context for work which continues after Slack has been responded to
+func detach(ctx context.Context) context.Context {
+	return detachedContext{Context: context.Background(), values: ctx}
+}
+
+// responseTimeout returns the deadline given to synchronous handlers
+func (h *SlackHandler) responseTimeout() time.Duration {
+	if h.ResponseTimeout > 0 {
+		return h.ResponseTimeout
+	}
+	return DefaultResponseTimeout
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestContextHandler(t *testing.T) {
+	var (
+		got      context.Context
+		payload  interface{}
+		received *Request
+	)
+	h := ContextHandler(func(ctx context.Context, res *Response, req *Request) error {
+		got, received = ctx, req
+		payload = Payload(ctx)
+		return nil
+	})
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h)
+	resp := performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	if RequestID(got) == "" {
+		t.Fatalf("Expected the request to be assigned an ID")
+	}
+	if rt := RouteFromContext(got); rt == nil || rt.Command != "/bob-test" {
+		t.Fatalf("Expected the matched route in the context. Got: %+v", rt)
+	}
+	if sc, ok := payload.(slack.SlashCommand); !ok || sc.UserID != "UABC123" {
+		t.Fatalf("Expected the slash command in the context. Got: %#v", payload)
+	}
+	deadline, ok := got.Deadline()
+	if !ok || deadline.After(time.Now().Add(DefaultResponseTimeout)) {
+		t.Fatalf("Expected a deadline within Slack's response window. Got: %s", deadline)
+	}
+	if got.Err() != context.Canceled {
+		t.Fatalf("Expected the context to be cancelled once the response was sent. Got: %v", got.Err())
+	}
+	if received.Context() != got {
+		t.Fatalf("Expected the request to carry the handler context")
+	}
+}
+
+func TestContextHandlerPayloadFromMiddleware(t *testing.T) {
+	var payload interface{}
+	h := ContextHandler(func(ctx context.Context, res *Response, req *Request) error {
+		payload = Payload(ctx)
+		return nil
+	})
+	replace := func(next SlackHandlerFunc) SlackHandlerFunc {
+		return func(res *Response, req *Request, ctx interface{}) error {
+			return next(res, req, "replaced")
+		}
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h, WithMiddleware(replace))
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	if payload != "replaced" {
+		t.Fatalf("Expected the payload passed by middleware. Got: %#v", payload)
+	}
+}
+
+func TestAsyncContextIsDetached(t *testing.T) {
+	done := make(chan context.Context, 1)
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		// Wait until the HTTP request has completed
+		time.Sleep(10 * time.Millisecond)
+		done <- req.Context()
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h, Async())
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	select {
+	case ctx := <-done:
+		if ctx.Err() != nil {
+			t.Fatalf("Async handlers should not be cancelled with the request: %s", ctx.Err())
+		}
+		if _, ok := ctx.Deadline(); ok {
+			t.Fatalf("Async handlers should not be bound by Slack's response window")
+		}
+		if RequestID(ctx) == "" || RouteFromContext(ctx) == nil {
+			t.Fatalf("Expected the request values to be kept")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Async handler was not executed")
+	}
+}
+
+func TestResponseTimeout(t *testing.T) {
+	h := ContextHandler(func(ctx context.Context, res *Response, req *Request) error {
+		select {
+		case <-ctx.Done():
+			res.Text(200, "Gave up")
+		case <-time.After(time.Second):
+			res.Text(200, "Finished")
+		}
+		return nil
+	})
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.ResponseTimeout = 10 * time.Millisecond
+	s.HandleCommand("/bob-test", h)
+	resp := performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	var body [8]byte
+	n, _ := resp.Body.Read(body[:])
+	if string(body[:n]) != "Gave up\n" {
+		t.Fatalf("Expected the handler to observe the deadline. Got: %q", body[:n])
+	}
+}
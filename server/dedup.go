@@ -0,0 +1,149 @@
+package server
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Defaults for the in-memory SeenStore which New creates. Slack retries an event up to
+// three times over several minutes
+const (
+	DefaultSeenSize = 10000
+	DefaultSeenTTL  = 15 * time.Minute
+)
+
+// ErrSeenStoreFull is returned by a store created with NewMemoryReplayStore when every ID it
+// holds must still be remembered
+var ErrSeenStoreFull = errors.New("seen store is full")
+
+// SeenStore records the IDs of events which have been received so that retried deliveries
+// are only handled once. Implement it with a shared store when running more than one instance
+type SeenStore interface {
+	// Seen records id and reports whether it had already been recorded
+	Seen(id string) (bool, error)
+}
+
+// ExpiringSeenStore is a SeenStore which can be told how long to remember each ID. The
+// ReplayStore is passed the validation window and clock skew in force for every request
+type ExpiringSeenStore interface {
+	SeenStore
+	// SeenFor records id for ttl and reports whether it had already been recorded
+	SeenFor(id string, ttl time.Duration) (bool, error)
+}
+
+// MemorySeenStore is a SeenStore which keeps up to size IDs in memory until they have not
+// been seen for ttl. The least recently seen IDs are forgotten first when it is full, unless
+// it was created by NewMemoryReplayStore
+type MemorySeenStore struct {
+	size    int
+	ttl     time.Duration
+	strict  bool
+	now     func() time.Time
+	mu      sync.Mutex
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+type seenEntry struct {
+	id      string
+	expires time.Time
+}
+
+// NewMemorySeenStore returns an empty MemorySeenStore
+func NewMemorySeenStore(size int, ttl time.Duration) *MemorySeenStore {
+	return &MemorySeenStore{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: list.New(),
+		index:   map[string]*list.Element{},
+	}
+}
+
+// NewMemoryReplayStore returns an empty MemorySeenStore which remembers every ID for the
+// ttl passed to SeenFor, or the default validation window and clock skew when Seen is used
+// It fails with ErrSeenStoreFull rather than forget an ID early, and an ID is not remembered
+// for any longer when it is seen again
+func NewMemoryReplayStore(size int) *MemorySeenStore {
+	s := NewMemorySeenStore(size, DefaultValidationWindow+DefaultClockSkew)
+	s.strict = true
+	return s
+}
+
+// Seen satisfies the SeenStore interface
+func (s *MemorySeenStore) Seen(id string) (bool, error) {
+	return s.SeenFor(id, s.ttl)
+}
+
+// SeenFor satisfies the ExpiringSeenStore interface
+func (s *MemorySeenStore) SeenFor(id string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.expire(now, false)
+	if e, ok := s.index[id]; ok {
+		entry := e.Value.(*seenEntry)
+		if now.Before(entry.expires) {
+			if !s.strict {
+				entry.expires = now.Add(ttl)
+				s.entries.MoveToFront(e)
+			}
+			return true, nil
+		}
+		s.remove(e)
+	}
+	if s.entries.Len() >= s.size && s.strict {
+		if s.expire(now, true); s.entries.Len() >= s.size {
+			return false, ErrSeenStoreFull
+		}
+	}
+	s.index[id] = s.entries.PushFront(&seenEntry{id: id, expires: now.Add(ttl)})
+	for s.entries.Len() > s.size {
+		s.remove(s.entries.Back())
+	}
+	return false, nil
+}
+
+// expire forgets IDs which have expired. Entries are kept in the order in which they were
+// last seen so only the oldest are checked, unless all is set. That is only needed when the
+// ttl has changed and newer entries can expire first
+func (s *MemorySeenStore) expire(now time.Time, all bool) {
+	for e := s.entries.Back(); e != nil; {
+		prev := e.Prev()
+		if !now.Before(e.Value.(*seenEntry).expires) {
+			s.remove(e)
+		} else if !all {
+			return
+		}
+		e = prev
+	}
+}
+
+func (s *MemorySeenStore) remove(e *list.Element) {
+	s.entries.Remove(e)
+	delete(s.index, e.Value.(*seenEntry).id)
+}
+
+// duplicate reports whether an event has already been received, logging the retry if it has
+// Events are recorded before they are handled, so a retry of an event whose handler failed is skipped
+func (h *SlackHandler) duplicate(req *Request, event *slackevents.EventsAPIEvent) bool {
+	cb, ok := event.Data.(*slackevents.EventsAPICallbackEvent)
+	if h.SeenStore == nil || !ok || cb.EventID == "" {
+		return false
+	}
+	seen, err := h.SeenStore.Seen(cb.EventID)
+	if err != nil {
+		h.logger().Error(fmt.Sprintf("Failed to check for a duplicate event: %s", err), requestFields(req.Context(), event))
+		return false
+	}
+	if seen {
+		h.metrics().Rejected(RejectedDuplicate)
+		h.logger().Info(fmt.Sprintf("Skipping duplicate event %s, retry %s: %s", cb.EventID, req.Header.Get("X-Slack-Retry-Num"), req.Header.Get("X-Slack-Retry-Reason")), requestFields(req.Context(), event))
+	}
+	return seen
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const retriedEvent = `{"type":"event_callback","event_id":"Ev0PV52K21","event":{"type":"emoji_changed","subtype":"remove","names":["test_emoji"]}}`
+
+// deliver sends an event as Slack would on its nth attempt
+func deliver(raw string, attempt int, s *SlackHandler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", basePath, bytes.NewBufferString(raw))
+	req.Header.Set("Content-Type", "application/json")
+	// Slack signs every attempt afresh
+	addSlackHeadersAt(raw, req, time.Now().Add(-time.Duration(attempt)*time.Second))
+	if attempt > 0 {
+		req.Header.Set("X-Slack-Retry-Num", strconv.Itoa(attempt))
+		req.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestEventRetriesAreSkipped(t *testing.T) {
+	calls := 0
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleEventCallback("emoji_changed", func(res *Response, req *Request, ctx interface{}) error {
+		calls++
+		return nil
+	})
+
+	for attempt := 0; attempt < 4; attempt++ {
+		if w := deliver(retriedEvent, attempt, s); w.Code != 200 {
+			t.Fatalf("Expected retries to be acknowledged. Got %d", w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("Expected the event to be handled once. Got %d", calls)
+	}
+	if logString != "Skipping duplicate event Ev0PV52K21, retry 3: http_timeout" {
+		t.Fatalf("Unexpected log: %s", logString)
+	}
+
+	deliver(`{"type":"event_callback","event_id":"Ev0PV52K22","event":{"type":"emoji_changed"}}`, 0, s)
+	if calls != 2 {
+		t.Fatalf("Expected a different event to be handled")
+	}
+}
+
+func TestEventDeduplicationDisabled(t *testing.T) {
+	calls := 0
+	s := New(WithSigningSecret(slackSecret), WithBasePath(basePath), WithSeenStore(nil), WithNoRetry())
+	s.HandleEventCallback("emoji_changed", func(res *Response, req *Request, ctx interface{}) error {
+		calls++
+		return nil
+	})
+
+	for attempt := 0; attempt < 2; attempt++ {
+		w := deliver(retriedEvent, attempt, s)
+		if w.Header().Get("X-Slack-No-Retry") != "1" {
+			t.Fatalf("Expected Slack to be told not to retry")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("Expected every delivery to be handled. Got %d", calls)
+	}
+}
+
+type failingSeenStore struct{}
+
+func (failingSeenStore) Seen(id string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestSeenStoreFailure(t *testing.T) {
+	calls := 0
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.SeenStore = failingSeenStore{}
+	s.HandleEventCallback("emoji_changed", func(res *Response, req *Request, ctx interface{}) error {
+		calls++
+		return nil
+	})
+	w := deliver(retriedEvent, 0, s)
+	if w.Header().Get("X-Slack-No-Retry") != "" {
+		t.Fatalf("Slack should be allowed to retry by default")
+	}
+	if calls != 1 {
+		t.Fatalf("Expected the event to be handled when the store fails")
+	}
+}
+
+func TestMemorySeenStore(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	s := NewMemorySeenStore(2, time.Minute)
+	s.now = func() time.Time { return now }
+	seen := func(id string) bool {
+		ok, _ := s.Seen(id)
+		return ok
+	}
+
+	if seen("a") || !seen("a") {
+		t.Fatalf("Expected a to be recorded")
+	}
+	seen("b")
+	now = now.Add(30 * time.Second)
+	seen("a")
+	seen("c") // Evicts b, the least recently seen
+	if seen("b") {
+		t.Fatalf("Expected b to be evicted")
+	}
+	// b was recorded again, evicting a
+	now = now.Add(59 * time.Second)
+	if !seen("c") {
+		t.Fatalf("Expected c to be remembered within the TTL")
+	}
+	now = now.Add(time.Minute)
+	if seen("c") {
+		t.Fatalf("Expected c to have expired")
+	}
+	if len(s.index) != 1 || s.entries.Len() != 1 {
+		t.Fatalf("Expected expired entries to be removed. Got %d", len(s.index))
+	}
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	s := NewMemoryReplayStore(2)
+	s.now = func() time.Time { return now }
+	seen := func(id string, ttl time.Duration) (bool, error) {
+		return s.SeenFor(id, ttl)
+	}
+
+	seen("a", time.Minute)
+	seen("b", 5*time.Minute)
+	// Nothing is forgotten within its ttl, so the store is full
+	if ok, err := seen("c", time.Minute); ok || !errors.Is(err, ErrSeenStoreFull) {
+		t.Fatalf("Expected the store to be full. Got %t %v", ok, err)
+	}
+	now = now.Add(30 * time.Second)
+	if ok, _ := seen("a", time.Minute); !ok {
+		t.Fatalf("Expected a to be remembered within its ttl")
+	}
+	// Seeing a again does not extend its ttl, so it expires and makes room for c
+	now = now.Add(30 * time.Second)
+	if ok, err := seen("c", time.Minute); ok || err != nil {
+		t.Fatalf("Expected c to be recorded. Got %t %v", ok, err)
+	}
+	if ok, _ := seen("b", time.Minute); !ok {
+		t.Fatalf("Expected b to be remembered for its own ttl")
+	}
+	// b was recorded before c but expires after it
+	now = now.Add(2 * time.Minute)
+	if ok, err := seen("d", time.Minute); ok || err != nil {
+		t.Fatalf("Expected c to have expired and made room for d. Got %t %v", ok, err)
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/slack-go/slack"
+)
+
+// DefaultErrorMessage is shown to the user when a handler fails with an internal error
+// and SlackHandler.ErrorMessage is not set
+const DefaultErrorMessage = "Sorry, something went wrong. Please try again later"
+
+// UserError is returned by a handler when the request can not be completed because of
+// something the user can fix. Its message is shown to them instead of the ErrorMessage
+type UserError struct {
+	Message string
+}
+
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+// UserErrorf formats a UserError, e.g. UserErrorf("Ticket *%s* does not exist", id)
+func UserErrorf(format string, a ...interface{}) error {
+	return &UserError{Message: fmt.Sprintf(format, a...)}
+}
+
+// PanicError is returned in place of the error from a handler which panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// responseTracker records whether a handler has started writing its response
+type responseTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (t *responseTracker) WriteHeader(code int) {
+	t.written = true
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *responseTracker) Write(b []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(b)
+}
+
+// call executes a handler, converting a panic in to a PanicError
+func call(f SlackHandlerFunc, res *Response, req *Request, ctx interface{}) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	return f(res, req, ctx)
+}
+
+// errorMessage returns the message which tells the user that their request failed
+func (h *SlackHandler) errorMessage(err error) string {
+	var ue *UserError
+	if errors.As(err, &ue) {
+		return ue.Message
+	}
+	if h.ErrorMessage != "" {
+		return h.ErrorMessage
+	}
+	return DefaultErrorMessage
+}
+
+// logError logs the error from a handler using format, which has a single verb for the error
+// The stack trace of a panic is logged first. User errors are expected so are not logged as errors
+// The request body is attached to the entry, after the RedactBody hook has been applied
+func (h *SlackHandler) logError(req *Request, format string, err error) {
+	log := LoggerFromContext(req.Context())
+	if body := h.redactedBody(req); body != "" {
+		log = log.WithFields(Fields{"body": body})
+	}
+	var ue *UserError
+	if errors.As(err, &ue) {
+		log.Info(fmt.Sprintf(format, err), nil)
+		return
+	}
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		log.Error(fmt.Sprintf("Recovered from handler panic: %s", pe.Stack), nil)
+	}
+	log.Error(fmt.Sprintf(format, err), nil)
+}
+
+// respondError shows the user an ephemeral message saying that their slash command or
+// interaction failed. A slash command which has not been responded to is answered directly,
+// otherwise the response_url is used. A modal submission has no response_url so the error is
+// shown in the modal, which stays open. Routes registered with HandlePath are answered with
+// a 500 and events have nobody to tell
+func (h *SlackHandler) respondError(res *Response, req *Request, ctx interface{}, written bool, err error) {
+	msg := &slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: h.errorMessage(err)}
+	switch c := ctx.(type) {
+	case slack.SlashCommand:
+		if !written {
+			res.JSON(http.StatusOK, msg)
+			return
+		}
+	case *slack.InteractionCallback:
+		if c.Type == slack.InteractionTypeViewSubmission {
+			if !written {
+				res.JSON(http.StatusOK, viewSubmissionError(c.View, msg.Text))
+			}
+			return
+		}
+	case nil:
+		if !written {
+			res.Text(http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+	if req.ResponseURL() == "" {
+		return
+	}
+	if err := req.PostResponse(msg); err != nil {
+		LoggerFromContext(req.Context()).Error(fmt.Sprintf("Failed to tell the user a request failed: %s", err), nil)
+	}
+}
+
+// viewSubmissionError returns the response to a failed modal submission. The message is shown
+// under the modal's first input so that what the user entered is kept, or in place of the modal
+// when it has no inputs
+func viewSubmissionError(v slack.View, text string) *slack.ViewSubmissionResponse {
+	for _, b := range v.Blocks.BlockSet {
+		if input, ok := b.(*slack.InputBlock); ok && input.BlockID != "" {
+			return slack.NewErrorsViewSubmissionResponse(map[string]string{input.BlockID: text})
+		}
+	}
+	title := v.Title
+	if title == nil {
+		title = slack.NewTextBlockObject(slack.PlainTextType, "Error", false, false)
+	}
+	return slack.NewUpdateViewSubmissionResponse(&slack.ModalViewRequest{
+		Type:   slack.VTModal,
+		Title:  title,
+		Close:  slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)}},
+	})
+}
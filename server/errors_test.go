@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestHandlerErrorResponses(t *testing.T) {
+	tt := []struct {
+		name    string
+		handler SlackHandlerFunc
+		message string
+		want    string
+		log     string
+	}{
+		{
+			"Internal error",
+			func(res *Response, req *Request, ctx interface{}) error { return fmt.Errorf("database is down") },
+			"",
+			DefaultErrorMessage,
+			"HTTP handler error: database is down",
+		},
+		{
+			"Custom message",
+			func(res *Response, req *Request, ctx interface{}) error { return fmt.Errorf("database is down") },
+			"The help desk is having problems, please call 1234",
+			"The help desk is having problems, please call 1234",
+			"HTTP handler error: database is down",
+		},
+		{
+			"User error",
+			func(res *Response, req *Request, ctx interface{}) error {
+				return fmt.Errorf("status: %w", UserErrorf("Ticket *%s* does not exist", "HD-42"))
+			},
+			"",
+			"Ticket *HD-42* does not exist",
+			"HTTP handler error: status: Ticket *HD-42* does not exist",
+		},
+		{
+			"Panic",
+			func(res *Response, req *Request, ctx interface{}) error { panic("boom") },
+			"",
+			DefaultErrorMessage,
+			"HTTP handler error: panic: boom",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+			s.ErrorMessage = tc.message
+			s.HandleCommand("/bob-test", tc.handler)
+			resp := performGenericFormRequest(slashCommandRaw, basePath, s)
+
+			var msg slack.Msg
+			if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+				t.Fatalf("Expected a JSON response: %s", err)
+			}
+			if resp.StatusCode != 200 || msg.ResponseType != slack.ResponseTypeEphemeral || msg.Text != tc.want {
+				t.Fatalf("Unexpected response: %d %+v", resp.StatusCode, msg)
+			}
+			if logString != tc.log {
+				t.Fatalf("Unexpected log: %s", logString)
+			}
+		})
+	}
+}
+
+func TestPanicStackIsLogged(t *testing.T) {
+	var logged []string
+	elf := func(msg string, i ...interface{}) {
+		logged = append(logged, fmt.Sprintf(msg, i...))
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, elf)
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		var m map[string]string
+		m["boom"] = "panic"
+		return nil
+	})
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+	if len(logged) < 2 || !strings.Contains(logged[0], "Recovered from handler panic: goroutine") || !strings.Contains(logged[0], "errors_test.go") {
+		t.Fatalf("Expected the stack trace to be logged. Got %v", logged)
+	}
+	if logged[1] != "HTTP handler error: panic: assignment to entry in nil map" {
+		t.Fatalf("Unexpected error log: %s", logged[1])
+	}
+}
+
+func TestErrorsUseResponseURL(t *testing.T) {
+	posted := make(chan slack.Msg, 1)
+	slackStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Msg
+		json.NewDecoder(r.Body).Decode(&msg)
+		posted <- msg
+	}))
+	defer slackStub.Close()
+
+	tt := []struct {
+		name string
+		opts []RouteOption
+		h    SlackHandlerFunc
+	}{
+		{"After responding", nil, func(res *Response, req *Request, ctx interface{}) error {
+			res.Text(200, "Working on it")
+			return fmt.Errorf("failed after responding")
+		}},
+		{"Async", []RouteOption{Async()}, func(res *Response, req *Request, ctx interface{}) error {
+			panic("async boom")
+		}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+			s.HandleCommand("/bob-test", tc.h, tc.opts...)
+			performGenericFormRequest(slashCommandWithResponseURL(slackStub.URL), basePath, s)
+			select {
+			case msg := <-posted:
+				if msg.Text != DefaultErrorMessage || msg.ResponseType != slack.ResponseTypeEphemeral {
+					t.Fatalf("Unexpected error response: %+v", msg)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for the error response")
+			}
+		})
+	}
+}
+
+func TestEventErrorsAreNotAnswered(t *testing.T) {
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleEventCallback("emoji_changed", func(res *Response, req *Request, ctx interface{}) error {
+		panic("boom")
+	})
+	resp := performGenericJsonRequest(`{"event":{"type":"emoji_changed"},"type":"event_callback"}`, basePath, s)
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || len(body) != 0 {
+		t.Fatalf("Expected an empty 200 for a failed event. Got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestViewSubmissionErrors(t *testing.T) {
+	tt := []struct {
+		name   string
+		view   string
+		action slack.ViewResponseAction
+	}{
+		{"With an input", `"blocks":[{"type":"section","text":{"type":"mrkdwn","text":"Help"}},{"type":"input","block_id":"description","label":{"type":"plain_text","text":"Description"},"element":{"type":"plain_text_input","action_id":"value"}}]`, slack.RAErrors},
+		{"Without an input", `"title":{"type":"plain_text","text":"Help"},"blocks":[]`, slack.RAUpdate},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+			s.HandleViewSubmission("HelpRequest", func(res *Response, req *Request, ctx interface{}) error {
+				return UserErrorf("Ticket *%s* does not exist", "HD-42")
+			})
+			resp := performGenericFormRequest(interactionRaw(`{"type":"view_submission","user":{"id":"W12A3BCDEF"},"view":{"type":"modal","callback_id":"HelpRequest",`+tc.view+`}}`), basePath, s)
+
+			var vr slack.ViewSubmissionResponse
+			if err := json.NewDecoder(resp.Body).Decode(&vr); err != nil {
+				t.Fatalf("Expected a JSON response: %s", err)
+			}
+			if resp.StatusCode != 200 || vr.ResponseAction != tc.action {
+				t.Fatalf("Unexpected response: %d %+v", resp.StatusCode, vr)
+			}
+			if tc.action == slack.RAErrors && vr.Errors["description"] != "Ticket *HD-42* does not exist" {
+				t.Fatalf("Expected the error to be shown under the input. Got %+v", vr.Errors)
+			}
+			if tc.action == slack.RAUpdate && (vr.View.Title.Text != "Help" || len(vr.View.Blocks.BlockSet) != 1) {
+				t.Fatalf("Expected the modal to show the error. Got %+v", vr.View)
+			}
+		})
+	}
+}
+
+func TestPathErrorsAreServerErrors(t *testing.T) {
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandlePath("/healthcheck", func(res *Response, req *Request, ctx interface{}) error {
+		return fmt.Errorf("unhealthy")
+	})
+	resp := performGenericFormRequest("", "/healthcheck", s)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected a 500 for a failed path route. Got %d", resp.StatusCode)
+	}
+}
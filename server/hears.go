@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Message is a message or app mention heard by a route registered with Hears
+type Message struct {
+	Event           *slackevents.EventsAPIEvent
+	Type            string // slackevents.Message or slackevents.AppMention
+	Channel         string
+	ChannelType     string // im, mpim, channel or group. Empty for app mentions
+	User            string
+	BotID           string
+	Text            string // The text with any leading mention of the app removed
+	RawText         string
+	TimeStamp       string
+	ThreadTimeStamp string
+	Edited          bool
+	Params          map[string]string // Named groups captured by the Hears expression
+}
+
+// DM reports whether the message was sent directly to the app
+func (m *Message) DM() bool {
+	return m.ChannelType == "im" || strings.HasPrefix(m.Channel, "D")
+}
+
+// InThread reports whether the message is a reply in a thread
+func (m *Message) InThread() bool {
+	return m.ThreadTimeStamp != "" && m.ThreadTimeStamp != m.TimeStamp
+}
+
+// ThreadTS returns the timestamp to reply to so that the reply is threaded under the message,
+// or alongside it when it is already in a thread
+func (m *Message) ThreadTS() string {
+	if m.ThreadTimeStamp != "" {
+		return m.ThreadTimeStamp
+	}
+	return m.TimeStamp
+}
+
+// MessageHandlerFunc handles a message heard by a route registered with Hears
+type MessageHandlerFunc func(res *Response, req *Request, m *Message) error
+
+// HearOption filters the messages a Hears route is interested in
+type HearOption func(*hearer)
+
+// Threads controls whether a Hears route handles messages in threads
+type Threads int
+
+// Thread filters
+const (
+	AnyThread     Threads = iota // Handle messages whether they are in a thread or not, the default
+	OnlyThreads                  // Only handle replies in a thread
+	IgnoreThreads                // Only handle messages which are not in a thread
+)
+
+type hearer struct {
+	match    func(text string) (map[string]string, bool)
+	handler  MessageHandlerFunc
+	channels []string
+	dm       bool
+	mention  bool
+	bots     bool
+	edits    bool
+	threads  Threads
+}
+
+// InChannels only hears messages in the given channels
+func InChannels(ids ...string) HearOption {
+	return func(hr *hearer) {
+		hr.channels = append(hr.channels, ids...)
+	}
+}
+
+// DirectMessages only hears messages sent directly to the app
+func DirectMessages() HearOption {
+	return func(hr *hearer) {
+		hr.dm = true
+	}
+}
+
+// Mentioned only hears messages addressed to the app, app mentions and direct messages
+// Use it when subscribed to both message and app_mention events so a mention is only handled once
+func Mentioned() HearOption {
+	return func(hr *hearer) {
+		hr.mention = true
+	}
+}
+
+// IncludeBots hears messages posted by bots, which are ignored by default
+func IncludeBots() HearOption {
+	return func(hr *hearer) {
+		hr.bots = true
+	}
+}
+
+// IncludeEdits hears edited messages, matching on their new text. Edits are ignored by default
+func IncludeEdits() HearOption {
+	return func(hr *hearer) {
+		hr.edits = true
+	}
+}
+
+// InThreads controls whether messages in threads are heard
+func InThreads(t Threads) HearOption {
+	return func(hr *hearer) {
+		hr.threads = t
+	}
+}
+
+// Hears registers a handler for messages and app mentions whose text matches the regular
+// expression expr. Named groups are available from Message.Params and RouteParams
+// Routes are tried in the order in which they are registered and only the first match is handled
+// Hears handles every message and app_mention event, so it fails with ErrDuplicateRoute if a
+// route for either has been registered with HandleEventCallback
+func (h *SlackHandler) Hears(expr string, f MessageHandlerFunc, opts ...HearOption) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	return h.hears(&hearer{handler: f, match: func(text string) (map[string]string, bool) {
+		m := re.FindStringSubmatch(text)
+		if m == nil {
+			return nil, false
+		}
+		params := map[string]string{}
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				params[name] = m[i]
+			}
+		}
+		return params, true
+	}}, opts)
+}
+
+// HearsKeywords registers a handler for messages and app mentions containing any of the
+// keywords as a whole word, ignoring case. The keyword found is available as the keyword param
+func (h *SlackHandler) HearsKeywords(keywords []string, f MessageHandlerFunc, opts ...HearOption) error {
+	if len(keywords) == 0 {
+		return fmt.Errorf("no keywords given")
+	}
+	quoted := make([]string, len(keywords))
+	for i, k := range keywords {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	return h.Hears(`(?i)\b(?P<keyword>`+strings.Join(quoted, "|")+`)\b`, f, opts...)
+}
+
+// hears registers the message and app_mention routes the first time it is called and adds
+// the hearer to those which they dispatch to. Both routes are validated before either is
+// registered, so a failure leaves the handler as it was and can be retried
+func (h *SlackHandler) hears(hr *hearer, opts []HearOption) error {
+	if hr.handler == nil {
+		return ErrNoHandler
+	}
+	for _, o := range opts {
+		o(hr)
+	}
+	if !h.hearsRegistered {
+		var routes []*Route
+		for _, et := range []string{slackevents.Message, slackevents.AppMention} {
+			r := &Route{Path: h.basePath, EventType: et, Handler: EventHandlerFunc(h.hear).handler()}
+			if err := h.validate(r); err != nil {
+				return err
+			}
+			routes = append(routes, r)
+		}
+		h.routes = append(h.routes, routes...)
+		h.hearsRegistered = true
+	}
+	h.hearers = append(h.hearers, hr)
+	return nil
+}
+
+// hear dispatches a message or app mention to the first Hears route which matches it
+func (h *SlackHandler) hear(res *Response, req *Request, e *slackevents.EventsAPIEvent) error {
+	m := newMessage(e)
+	if m == nil {
+		return nil
+	}
+	for _, hr := range h.hearers {
+		if !hr.accepts(m) {
+			continue
+		}
+		if params, ok := hr.match(m.Text); ok {
+			m.Params = params
+			return hr.handler(res, req.withContext(context.WithValue(req.Context(), paramsKey, params)), m)
+		}
+	}
+	return nil
+}
+
+// accepts reports whether a message passes the filters of the hearer
+func (hr *hearer) accepts(m *Message) bool {
+	switch {
+	case m.BotID != "" && !hr.bots:
+		return false
+	case m.Edited && !hr.edits:
+		return false
+	case hr.dm && !m.DM():
+		return false
+	case hr.mention && m.Type != slackevents.AppMention && !m.DM():
+		return false
+	case hr.threads == OnlyThreads && !m.InThread(), hr.threads == IgnoreThreads && m.InThread():
+		return false
+	}
+	if len(hr.channels) == 0 {
+		return true
+	}
+	for _, c := range hr.channels {
+		if c == m.Channel {
+			return true
+		}
+	}
+	return false
+}
+
+// leadingMention matches a mention of the app at the start of an app mention
+var leadingMention = regexp.MustCompile(`^\s*<@[A-Z0-9]+(\|[^>]*)?>[\s:,]*`)
+
+// newMessage builds a Message from a message or app_mention event
+// nil is returned for message subtypes which do not represent something a user said
+func newMessage(e *slackevents.EventsAPIEvent) *Message {
+	m := &Message{Event: e, Type: e.InnerEvent.Type}
+	switch ev := e.InnerEvent.Data.(type) {
+	case *slackevents.AppMentionEvent:
+		m.Channel, m.User, m.BotID, m.RawText = ev.Channel, ev.User, ev.BotID, ev.Text
+		m.TimeStamp, m.ThreadTimeStamp = ev.TimeStamp, ev.ThreadTimeStamp
+		m.Text = leadingMention.ReplaceAllString(ev.Text, "")
+		return m
+	case *slackevents.MessageEvent:
+		m.Channel, m.ChannelType = ev.Channel, ev.ChannelType
+		switch ev.SubType {
+		case "", "bot_message", "thread_broadcast", "me_message", "file_share":
+		case "message_changed":
+			if ev.Message == nil {
+				return nil
+			}
+			m.Edited = true
+			ev = ev.Message
+		default:
+			return nil
+		}
+		m.User, m.BotID, m.RawText, m.Text = ev.User, ev.BotID, ev.Text, ev.Text
+		m.TimeStamp, m.ThreadTimeStamp = ev.TimeStamp, ev.ThreadTimeStamp
+		return m
+	}
+	return nil
+}
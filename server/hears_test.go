@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// newHearsHandler registers hears routes which record the name of the route and the message it heard
+func newHearsHandler(got *string) *SlackHandler {
+	record := func(name string) MessageHandlerFunc {
+		return func(res *Response, req *Request, m *Message) error {
+			*got = fmt.Sprintf("%s %q %v", name, m.Text, RouteParams(req.Context()))
+			return nil
+		}
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.Hears(`^status (?P<ticket>HD-\d+)$`, record("status"), InThreads(IgnoreThreads))
+	s.Hears(`(?i)vpn`, record("vpn"), Mentioned())
+	s.HearsKeywords([]string{"deploy", "release"}, record("ops"), InChannels("C0OPS"), IncludeEdits())
+	s.HearsKeywords([]string{"thanks"}, record("thanks"), InThreads(OnlyThreads), IncludeBots())
+	s.Hears(`^hello$`, record("dm"), DirectMessages())
+	return s
+}
+
+func TestHears(t *testing.T) {
+	message := func(fields string) string {
+		return fmt.Sprintf(`{"type":"event_callback","event":{"type":"message","user":"U123","ts":"1.1",%s}}`, fields)
+	}
+	tt := []struct {
+		name, event, want string
+	}{
+		{"regexp", message(`"channel":"C123","text":"status HD-12"`), `status "status HD-12" map[ticket:HD-12]`},
+		{"ignore threads", message(`"channel":"C123","text":"status HD-12","thread_ts":"1.0"`), ""},
+		{"mention", `{"type":"event_callback","event":{"type":"app_mention","user":"U123","channel":"C123","ts":"1.1","text":"<@U0HELPDESK> my VPN is down"}}`, `vpn "my VPN is down" map[]`},
+		{"not mentioned", message(`"channel":"C123","text":"my VPN is down"`), ""},
+		{"mentioned in a DM", message(`"channel":"D123","channel_type":"im","text":"my VPN is down"`), `vpn "my VPN is down" map[]`},
+		{"keyword", message(`"channel":"C0OPS","text":"Release is done!"`), `ops "Release is done!" map[keyword:Release]`},
+		{"keyword in another channel", message(`"channel":"C123","text":"Release is done!"`), ""},
+		{"partial keyword", message(`"channel":"C0OPS","text":"redeployed"`), ""},
+		{"edit", message(`"channel":"C0OPS","subtype":"message_changed","message":{"user":"U123","ts":"1.1","text":"deploy now"}`), `ops "deploy now" map[keyword:deploy]`},
+		{"ignore edits", message(`"channel":"C123","subtype":"message_changed","message":{"user":"U123","ts":"1.1","text":"status HD-1"}`), ""},
+		{"ignore bots", message(`"channel":"C123","bot_id":"B123","subtype":"bot_message","text":"status HD-1"`), ""},
+		{"include bots in threads", message(`"channel":"C123","bot_id":"B123","subtype":"bot_message","text":"thanks","thread_ts":"1.0"`), `thanks "thanks" map[keyword:thanks]`},
+		{"only threads", message(`"channel":"C123","text":"thanks"`), ""},
+		{"direct message", message(`"channel":"D123","channel_type":"im","text":"hello"`), `dm "hello" map[]`},
+		{"not a direct message", message(`"channel":"C123","channel_type":"channel","text":"hello"`), ""},
+		{"ignore joins", message(`"channel":"C123","subtype":"channel_join","text":"status HD-1"`), ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			s := newHearsHandler(&got)
+			resp := performGenericJsonRequest(tc.event, basePath, s)
+			if resp.StatusCode != 200 {
+				t.Fatalf("Expected a 200 status. Got %d", resp.StatusCode)
+			}
+			if got != tc.want {
+				t.Fatalf("Expected %s. Got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHearsRegistration(t *testing.T) {
+	h := func(res *Response, req *Request, m *Message) error {
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	if err := s.Hears(`(`, h); err == nil {
+		t.Fatalf("Expected an invalid expression to be rejected")
+	}
+	if err := s.Hears(`.`, nil); !errors.Is(err, ErrNoHandler) {
+		t.Fatalf("Expected a missing handler to be rejected. Got: %v", err)
+	}
+	if err := s.HearsKeywords(nil, h); err == nil {
+		t.Fatalf("Expected keywords to be required")
+	}
+	if err := s.Hears(`.`, h); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := s.Hears(`..`, h); err != nil {
+		t.Fatalf("Unexpected error adding a second route: %s", err)
+	}
+	if len(s.Routes()) != 2 {
+		t.Fatalf("Expected message and app_mention routes. Got %d routes", len(s.Routes()))
+	}
+}
+
+func TestMessageThreads(t *testing.T) {
+	m := &Message{TimeStamp: "1.1"}
+	if m.InThread() || m.ThreadTS() != "1.1" {
+		t.Fatalf("A message which is not in a thread should be replied to in a new thread")
+	}
+	m.ThreadTimeStamp = "1.1"
+	if m.InThread() || m.ThreadTS() != "1.1" {
+		t.Fatalf("The parent of a thread is not in the thread")
+	}
+	m.TimeStamp = "1.2"
+	if !m.InThread() || m.ThreadTS() != "1.1" {
+		t.Fatalf("A reply should be answered in the same thread")
+	}
+}
+
+func TestHearsConflict(t *testing.T) {
+	h := func(res *Response, req *Request, m *Message) error {
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleEventCallback("app_mention", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		if err := s.Hears(`.`, h); !errors.Is(err, ErrDuplicateRoute) || err.Error() != "event app_mention: route is already registered" {
+			t.Fatalf("Expected the app_mention route to conflict. Got: %v", err)
+		}
+	}
+	if len(s.Routes()) != 1 {
+		t.Fatalf("Expected the message route not to be registered. Got %d routes", len(s.Routes()))
+	}
+}
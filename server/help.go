@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// WithDescription describes what a route does in the generated help
+func WithDescription(d string) RouteOption {
+	return func(r *Route) {
+		r.Description = d
+	}
+}
+
+// WithUsage documents the text a command expects in the generated help, e.g. "<description of the problem>"
+func WithUsage(u string) RouteOption {
+	return func(r *Route) {
+		r.Usage = u
+	}
+}
+
+// WithExamples adds example invocations of a command to the generated help, e.g. "/page VPN is down"
+func WithExamples(e ...string) RouteOption {
+	return func(r *Route) {
+		r.Examples = append(r.Examples, e...)
+	}
+}
+
+// Help builds a Block Kit description of the registered commands, their subcommands and
+// any shortcuts which have a description
+func (h *SlackHandler) Help() []slack.Block {
+	mrkdwn := func(s string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.MarkdownType, s, false, false)
+	}
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Here's what I can do", false, false)),
+	}
+
+	var shortcuts []string
+	for _, rt := range h.routes {
+		switch {
+		case rt.Command != "":
+			blocks = append(blocks, slack.NewSectionBlock(mrkdwn(commandHelp(rt)), nil, nil))
+			if examples := commandExamples(rt); len(examples) > 0 {
+				blocks = append(blocks, slack.NewContextBlock("", mrkdwn("Examples: "+strings.Join(examples, ", "))))
+			}
+		case rt.Description != "" && rt.InteractionType == string(slack.InteractionTypeShortcut):
+			shortcuts = append(shortcuts, fmt.Sprintf("• %s _(shortcuts menu)_", rt.Description))
+		case rt.Description != "" && rt.InteractionType == string(slack.InteractionTypeMessageAction):
+			shortcuts = append(shortcuts, fmt.Sprintf("• %s _(message menu)_", rt.Description))
+		}
+	}
+	if len(shortcuts) > 0 {
+		blocks = append(blocks, slack.NewDividerBlock(), slack.NewSectionBlock(mrkdwn("*Shortcuts*\n"+strings.Join(shortcuts, "\n")), nil, nil))
+	}
+	return blocks
+}
+
+// HelpHandler returns a handler which responds with Help, visible only to the user
+// It can be mounted on any command, subcommand routers respond with it to `help`
+func (h *SlackHandler) HelpHandler() CommandHandlerFunc {
+	return func(res *Response, req *Request, sc slack.SlashCommand) error {
+		msg := &slack.Msg{Text: "Here's what I can do", Blocks: slack.Blocks{BlockSet: h.Help()}}
+		return ReplyEphemeral(res, req, msg)
+	}
+}
+
+// commandHelp describes a command route and its subcommands
+func commandHelp(rt *Route) string {
+	usage := rt.Command
+	if rt.Usage != "" {
+		usage += " " + rt.Usage
+	}
+	lines := []string{fmt.Sprintf("*`%s`*", usage)}
+	if rt.Description != "" {
+		lines = append(lines, rt.Description)
+	}
+	if rt.router != nil {
+		for _, s := range rt.router.subcommands {
+			line := fmt.Sprintf("• `%s`", s.usage(rt.Command))
+			if s.Description != "" {
+				line += " - " + s.Description
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commandExamples returns the examples of a command route and its subcommands
+func commandExamples(rt *Route) []string {
+	var examples []string
+	for _, e := range rt.Examples {
+		examples = append(examples, fmt.Sprintf("`%s`", e))
+	}
+	if rt.router != nil {
+		for _, s := range rt.router.subcommands {
+			for _, e := range s.Examples {
+				examples = append(examples, fmt.Sprintf("`%s %s`", rt.Command, e))
+			}
+		}
+	}
+	return examples
+}
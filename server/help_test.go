@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// helpText flattens the text of the help blocks so it can be compared
+func helpText(blocks []slack.Block) string {
+	var lines []string
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *slack.HeaderBlock:
+			lines = append(lines, "# "+b.Text.Text)
+		case *slack.SectionBlock:
+			lines = append(lines, b.Text.Text)
+		case *slack.ContextBlock:
+			lines = append(lines, "> "+b.ContextElements.Elements[0].(*slack.TextBlockObject).Text)
+		case *slack.DividerBlock:
+			lines = append(lines, "---")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newHelpHandler() *SlackHandler {
+	noop := func(res *Response, req *Request, ctx interface{}) error { return nil }
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/page", noop, WithDescription("Page on-call"), WithUsage("<problem>"), WithExamples("/page VPN is down"))
+	cr, _ := s.HandleSubcommands("/help-me", WithDescription("Ask for help"))
+	cr.Handle("status", "Show a ticket", nil, Arg("ticket", StringArg), Example("status HD-1"))
+	s.HandleShortcut("raise", noop, WithDescription("Raise a help request"))
+	s.HandleMessageShortcut("raise_from_message", noop, WithDescription("Ask for help with a message"))
+	s.HandleShortcut("undocumented", noop)
+	s.HandleBlockAction("claim", noop, WithDescription("Not a command"))
+	return s
+}
+
+func TestHelp(t *testing.T) {
+	want := strings.Join([]string{
+		"# Here's what I can do",
+		"*`/page <problem>`*\nPage on-call",
+		"> Examples: `/page VPN is down`",
+		"*`/help-me`*\nAsk for help\n• `/help-me status <ticket>` - Show a ticket",
+		"> Examples: `/help-me status HD-1`",
+		"---",
+		"*Shortcuts*\n• Raise a help request _(shortcuts menu)_\n• Ask for help with a message _(message menu)_",
+	}, "\n")
+	if got := helpText(newHelpHandler().Help()); got != want {
+		t.Fatalf("Unexpected help:\n%s", got)
+	}
+}
+
+func TestHelpHandler(t *testing.T) {
+	s := newHelpHandler()
+	s.HandleCommandFunc("/helpdesk", s.HelpHandler())
+
+	for _, raw := range []string{commandRaw("/helpdesk", ""), commandRaw("/help-me", "help")} {
+		resp := performGenericFormRequest(raw, basePath, s)
+		var msg slack.Msg
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			t.Fatalf("Expected a JSON response: %s", err)
+		}
+		if msg.ResponseType != "ephemeral" || len(msg.Blocks.BlockSet) != 8 {
+			t.Fatalf("Expected the help to be sent to the user. Got: %+v", msg)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// LogFunc is an abstraction that allows using any external logger with a Print signature
+// Set to nil to disable logging completely
+type LogFunc func(...interface{})
+
+// LogfFunc is an abstraction that allows using any external logger with a Printf signature
+// Set to nil to disable logging completely
+type LogfFunc func(string, ...interface{})
+
+// Fields are key/value pairs attached to a log entry, e.g. the team, user or request ID
+type Fields map[string]interface{}
+
+// Logger is a leveled, structured logger. Adapters are provided for logrus, log/slog
+// and print style functions
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Error(msg string, fields Fields)
+	// WithFields returns a Logger which adds fields to every entry
+	WithFields(fields Fields) Logger
+}
+
+// NopLogger discards everything logged to it
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, Fields)     {}
+func (nopLogger) Info(string, Fields)      {}
+func (nopLogger) Error(string, Fields)     {}
+func (nopLogger) WithFields(Fields) Logger { return NopLogger }
+
+// FuncLogger adapts print style functions to a Logger. Debug and Info entries are sent to
+// Logf, or Log if it is nil, and Error entries to ErrorLogf or ErrorLog. Fields are dropped
+// Any function may be nil to disable logging at that level
+type FuncLogger struct {
+	Log       LogFunc
+	Logf      LogfFunc
+	ErrorLog  LogFunc
+	ErrorLogf LogfFunc
+}
+
+// Debug satisfies the Logger interface
+func (l FuncLogger) Debug(msg string, fields Fields) {
+	l.Info(msg, fields)
+}
+
+// Info satisfies the Logger interface
+func (l FuncLogger) Info(msg string, fields Fields) {
+	printLog(l.Log, l.Logf, msg)
+}
+
+// Error satisfies the Logger interface
+func (l FuncLogger) Error(msg string, fields Fields) {
+	printLog(l.ErrorLog, l.ErrorLogf, msg)
+}
+
+// WithFields satisfies the Logger interface
+func (l FuncLogger) WithFields(fields Fields) Logger {
+	return l
+}
+
+func printLog(log LogFunc, logf LogfFunc, msg string) {
+	switch {
+	case logf != nil:
+		logf("%s", msg)
+	case log != nil:
+		log(msg)
+	}
+}
+
+// LoggerFromContext returns the logger for the request being handled. Its entries carry the
+// request ID and, where the payload has them, the team, user, channel and command or callback
+func LoggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey).(Logger); ok {
+		return l
+	}
+	return NopLogger
+}
+
+// logger returns the handler's Logger, which is never nil
+func (h *SlackHandler) logger() Logger {
+	if h.Logger == nil {
+		return NopLogger
+	}
+	return h.Logger
+}
+
+// requestFields describes the request being handled and its payload for logging
+func requestFields(ctx context.Context, payload interface{}) Fields {
+	f := Fields{"request_id": RequestID(ctx)}
+	add := func(k, v string) {
+		if v != "" {
+			f[k] = v
+		}
+	}
+	switch p := payload.(type) {
+	case slack.SlashCommand:
+		add("team_id", p.TeamID)
+		add("user_id", p.UserID)
+		add("channel_id", p.ChannelID)
+		add("command", p.Command)
+	case *BlockActionCallback:
+		add("action_id", p.Action.ActionID)
+		addInteraction(add, p.InteractionCallback)
+	case *slack.InteractionCallback:
+		addInteraction(add, p)
+	case *slackevents.EventsAPIEvent:
+		add("team_id", p.TeamID)
+		add("event_type", p.InnerEvent.Type)
+	}
+	return f
+}
+
+func addInteraction(add func(k, v string), p *slack.InteractionCallback) {
+	add("team_id", p.Team.ID)
+	add("user_id", p.User.ID)
+	add("channel_id", p.Channel.ID)
+	add("interaction_type", string(p.Type))
+	add("callback_id", interactionCallbackID(p))
+}
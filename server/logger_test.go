@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// recordingLogger keeps every entry logged to it with its fields
+type recordingLogger struct {
+	fields  Fields
+	entries *[]string
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{entries: &[]string{}}
+}
+
+func (l *recordingLogger) Debug(msg string, f Fields) { l.record("DEBUG", msg, f) }
+func (l *recordingLogger) Info(msg string, f Fields)  { l.record("INFO", msg, f) }
+func (l *recordingLogger) Error(msg string, f Fields) { l.record("ERROR", msg, f) }
+
+func (l *recordingLogger) WithFields(f Fields) Logger {
+	merged := Fields{}
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range f {
+		merged[k] = v
+	}
+	return &recordingLogger{fields: merged, entries: l.entries}
+}
+
+func (l *recordingLogger) record(level, msg string, f Fields) {
+	var kv []string
+	for k, v := range l.WithFields(f).(*recordingLogger).fields {
+		if k != "request_id" {
+			kv = append(kv, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	sort.Strings(kv)
+	*l.entries = append(*l.entries, strings.TrimSpace(fmt.Sprintf("%s %s %s", level, msg, strings.Join(kv, " "))))
+}
+
+func TestNilLogFuncs(t *testing.T) {
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, nil, nil, nil, nil)
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return fmt.Errorf("serious problem")
+	})
+	if resp := performGenericFormRequest(slashCommandRaw, basePath, s); resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	s.Logger = nil
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+}
+
+func TestFuncLoggerFallsBackToPrint(t *testing.T) {
+	var got []string
+	l := FuncLogger{
+		Log:      func(i ...interface{}) { got = append(got, fmt.Sprint(i...)) },
+		ErrorLog: func(i ...interface{}) { got = append(got, "error: "+fmt.Sprint(i...)) },
+	}
+	l.WithFields(Fields{"user_id": "U123"}).Info("100% done", nil)
+	l.Error("failed", Fields{"user_id": "U123"})
+	if strings.Join(got, ",") != "100% done,error: failed" {
+		t.Fatalf("Unexpected log: %v", got)
+	}
+}
+
+func TestRequestLogFields(t *testing.T) {
+	l := newRecordingLogger()
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, nil, nil, nil, nil)
+	s.Logger = l
+	var id string
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		id = RequestID(req.Context())
+		LoggerFromContext(req.Context()).Info("Looking up ticket", Fields{"ticket": "HD-1"})
+		return fmt.Errorf("not found")
+	})
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	fields := "channel_id=D8AD0L4UB command=/bob-test team_id=T01ABC user_id=UABC123"
+	want := []string{
+		"DEBUG Request signed with signing secret 1 of 1 path=/slack signing_secret=1",
+		"DEBUG slack command triggered: /bob-test " + fields,
+		"INFO Looking up ticket channel_id=D8AD0L4UB command=/bob-test team_id=T01ABC ticket=HD-1 user_id=UABC123",
+		"ERROR HTTP handler error: not found body=channel_id=D8AD0L4UB&channel_name=directmessage&command=/bob-test&response_url=REDACTED&team_domain=example&team_id=T01ABC&text=&token=REDACTED&trigger_id=400003447986.4709815545.5c0291e01b37fc97ab64d8d7888f6cda&user_id=UABC123&user_name=bob.smith " + fields,
+	}
+	if got := strings.Join(*l.entries, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("Unexpected log entries:\n%s", got)
+	}
+	if id == "" {
+		t.Fatalf("Expected a request ID")
+	}
+}
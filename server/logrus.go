@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// LogrusLogger adapts a logrus logger or entry to a Logger
+type LogrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// NewLogrusLogger returns a Logger which writes to l, e.g. NewLogrusLogger(logrus.StandardLogger())
+func NewLogrusLogger(l logrus.FieldLogger) *LogrusLogger {
+	return &LogrusLogger{l: l}
+}
+
+// Debug satisfies the Logger interface
+func (l *LogrusLogger) Debug(msg string, fields Fields) {
+	l.l.WithFields(logrus.Fields(fields)).Debug(msg)
+}
+
+// Info satisfies the Logger interface
+func (l *LogrusLogger) Info(msg string, fields Fields) {
+	l.l.WithFields(logrus.Fields(fields)).Info(msg)
+}
+
+// Error satisfies the Logger interface
+func (l *LogrusLogger) Error(msg string, fields Fields) {
+	l.l.WithFields(logrus.Fields(fields)).Error(msg)
+}
+
+// WithFields satisfies the Logger interface
+func (l *LogrusLogger) WithFields(fields Fields) Logger {
+	return &LogrusLogger{l: l.l.WithFields(logrus.Fields(fields))}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogrusLogger(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	l.SetLevel(logrus.DebugLevel)
+	log := NewLogrusLogger(l).WithFields(Fields{"request_id": "abc"})
+	log.Debug("debugging", nil)
+	log.Error("failed", Fields{"user_id": "U123"})
+
+	if len(hook.Entries) != 2 || hook.Entries[0].Level != logrus.DebugLevel {
+		t.Fatalf("Unexpected entries: %+v", hook.Entries)
+	}
+	e := hook.LastEntry()
+	if e.Level != logrus.ErrorLevel || e.Message != "failed" || e.Data["request_id"] != "abc" || e.Data["user_id"] != "U123" {
+		t.Fatalf("Unexpected entry: %+v", e)
+	}
+}
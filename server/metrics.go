@@ -0,0 +1,40 @@
+package server
+
+import "time"
+
+// Reasons passed to Metrics.Rejected
+const (
+	RejectedInvalid   = "invalid"   // The request failed validation for a reason below
+	RejectedStale     = "stale"     // The request's timestamp was older than the ValidationWindow
+	RejectedSkewed    = "skewed"    // The request's timestamp was further in the future than the ClockSkew
+	RejectedReplayed  = "replayed"  // The request's signature had already been used
+	RejectedSignature = "signature" // The request was not signed with any of the signing secrets
+	RejectedTooLarge  = "too_large" // The request body was larger than the MaxBodySize
+	RejectedSaturated = "saturated" // The Async worker pool was full
+	RejectedDuplicate = "duplicate" // The event had already been received
+)
+
+// Metrics is notified about the requests served so they can be exported to a monitoring
+// system such as Prometheus
+type Metrics interface {
+	// Handled is called when the handler for a route returns, with how long it took and its error
+	Handled(rt *Route, d time.Duration, err error)
+	// Rejected is called when a request is refused before it reaches a handler
+	Rejected(reason string)
+}
+
+// NopMetrics discards all metrics
+var NopMetrics Metrics = nopMetrics{}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Handled(*Route, time.Duration, error) {}
+func (nopMetrics) Rejected(string)                      {}
+
+// metrics returns the handler's Metrics, which is never nil
+func (h *SlackHandler) metrics() Metrics {
+	if h.Metrics == nil {
+		return NopMetrics
+	}
+	return h.Metrics
+}
@@ -0,0 +1,39 @@
+package server
+
+// Middleware wraps a SlackHandlerFunc to add behaviour before and/or after it is executed
+// A Middleware can short-circuit the chain by not calling next, pass a different context
+// downstream or inspect the error returned by the rest of the chain
+type Middleware func(next SlackHandlerFunc) SlackHandlerFunc
+
+// RouteOption configures a Route at registration time
+type RouteOption func(*Route)
+
+// WithMiddleware attaches middleware to a single route
+// Route middleware is executed after any middleware registered with Use
+func WithMiddleware(m ...Middleware) RouteOption {
+	return func(r *Route) {
+		r.Middleware = append(r.Middleware, m...)
+	}
+}
+
+// Use registers middleware which is applied to every route, including the DefaultRoute
+// Middleware is executed in the order in which it is registered
+func (h *SlackHandler) Use(m ...Middleware) {
+	h.middleware = append(h.middleware, m...)
+}
+
+// Chain wraps f in the supplied middleware so that m[0] is the outermost
+func Chain(f SlackHandlerFunc, m ...Middleware) SlackHandlerFunc {
+	for i := len(m) - 1; i >= 0; i-- {
+		f = m[i](f)
+	}
+	return f
+}
+
+// handlerFor builds the complete handler chain for a route
+func (h *SlackHandler) handlerFor(rt *Route) SlackHandlerFunc {
+	m := make([]Middleware, 0, len(h.middleware)+len(rt.Middleware))
+	m = append(m, h.middleware...)
+	m = append(m, rt.Middleware...)
+	return Chain(rt.Handler, m...)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+var slashCommandRaw = "token=TOKEN&team_id=T01ABC&team_domain=example&channel_id=D8AD0L4UB&channel_name=directmessage&user_id=UABC123&user_name=bob.smith&command=%2Fbob-test&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FABC123%2F123456%2FABC123&trigger_id=400003447986.4709815545.5c0291e01b37fc97ab64d8d7888f6cda"
+
+// recorder returns a Middleware which appends name to calls when it is executed
+func recorder(name string, calls *[]string) Middleware {
+	return func(next SlackHandlerFunc) SlackHandlerFunc {
+		return func(res *Response, req *Request, ctx interface{}) error {
+			*calls = append(*calls, name)
+			return next(res, req, ctx)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		calls = append(calls, "handler")
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.Use(recorder("global1", &calls), recorder("global2", &calls))
+	s.HandleCommand("/bob-test", h, WithMiddleware(recorder("route", &calls)))
+	resp := performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	if resp.StatusCode != 200 {
+		t.Logf("ErrString: %s", logString)
+		t.Fatalf("Expected a 200 status. Got '%d'", resp.StatusCode)
+	}
+	if got := strings.Join(calls, ","); got != "global1,global2,route,handler" {
+		t.Fatalf("Unexpected middleware order: %s", got)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	}
+	deny := func(next SlackHandlerFunc) SlackHandlerFunc {
+		return func(res *Response, req *Request, ctx interface{}) error {
+			if sc, ok := ctx.(slack.SlashCommand); ok && sc.UserID == "UABC123" {
+				res.Text(403, "Forbidden")
+				return nil
+			}
+			return next(res, req, ctx)
+		}
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h, WithMiddleware(deny))
+	resp := performGenericFormRequest(slashCommandRaw, basePath, s)
+
+	if resp.StatusCode != 403 {
+		t.Fatalf("Expected a 403 status. Got '%d'", resp.StatusCode)
+	}
+}
+
+func TestMiddlewareContextAndErrors(t *testing.T) {
+	var observed error
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		if ctx != "enriched" {
+			t.Fatalf("Expected the context to be replaced by middleware. Got '%v'", ctx)
+		}
+		return fmt.Errorf("handler failed")
+	}
+	enrich := func(next SlackHandlerFunc) SlackHandlerFunc {
+		return func(res *Response, req *Request, ctx interface{}) error {
+			return next(res, req, "enriched")
+		}
+	}
+	observe := func(next SlackHandlerFunc) SlackHandlerFunc {
+		return func(res *Response, req *Request, ctx interface{}) error {
+			observed = next(res, req, ctx)
+			return observed
+		}
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.Use(observe)
+	s.HandlePath("/foo", h, WithMiddleware(enrich))
+	performGenericFormRequest("foo=bar", "/foo", s)
+
+	if observed == nil || observed.Error() != "handler failed" {
+		t.Fatalf("Expected middleware to observe the handler error. Got '%v'", observed)
+	}
+	if logString != "HTTP handler error: handler failed" {
+		t.Fatalf("Unexpected error string: %s", logString)
+	}
+}
+
+func TestMiddlewareAppliesToDefaultRoute(t *testing.T) {
+	var calls []string
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.Use(recorder("global", &calls))
+	resp := performGenericFormRequest("foo=bar", "/nothing-here", s)
+
+	if resp.StatusCode != 404 {
+		t.Fatalf("Expected a 404 status. Got '%d'", resp.StatusCode)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("Expected middleware to run for the default route")
+	}
+}
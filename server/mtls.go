@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SlackClientName is the name in the client certificate Slack presents for mutual TLS
+const SlackClientName = "platform-tls-client.slack.com"
+
+// ErrInvalidClientCert is returned when a request does not carry a verified client certificate
+// for one of the expected names
+var ErrInvalidClientCert = errors.New("invalid client certificate")
+
+// ClientIdentity describes the client which sent a request, as verified by mutual TLS
+type ClientIdentity struct {
+	Name        string            // The expected name which the client matched, e.g. SlackClientName
+	Subject     string            // The client certificate's subject, or the DN header set by a proxy
+	Certificate *x509.Certificate // The verified client certificate, nil when a proxy terminated TLS
+}
+
+// ClientIdentityFromContext returns the verified client of the request being handled, or nil
+// when mutual TLS is not in use
+func ClientIdentityFromContext(ctx context.Context) *ClientIdentity {
+	id, _ := ctx.Value(clientIdentityKey).(*ClientIdentity)
+	return id
+}
+
+// MutualTLSConfig returns a tls.Config for an http.Server which requires every client to present
+// a certificate signed by one of the CAs in the PEM bundle at caFile
+func MutualTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA bundle: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+	}
+	return &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// WithMutualTLS requires requests to be received over TLS with a verified client certificate
+// whose subject common name or DNS SANs include one of names, SlackClientName by default
+// Serve the handler with a tls.Config from MutualTLSConfig. Use WithMutualTLSHeader instead
+// when a proxy terminates TLS
+func WithMutualTLS(names ...string) Option {
+	if len(names) == 0 {
+		names = []string{SlackClientName}
+	}
+	return func(h *SlackHandler) {
+		h.clientNames = names
+	}
+}
+
+// verifyClient checks the client which sent the request when mutual TLS is in use, returning
+// its identity. Without mutual TLS every client is accepted and the identity is nil
+func (h *SlackHandler) verifyClient(req *Request) (*ClientIdentity, error) {
+	if h.dnHeader != nil {
+		// A proxy terminated TLS and passed on the DN of the client certificate
+		dn := req.Header.Get(*h.dnHeader)
+		if !strings.Contains(dn, SlackClientName) {
+			return nil, ErrInvalidDN
+		}
+		return &ClientIdentity{Name: SlackClientName, Subject: dn}, nil
+	}
+	if h.clientNames == nil {
+		return nil, nil
+	}
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		return nil, fmt.Errorf("%w: no verified certificate was presented", ErrInvalidClientCert)
+	}
+	cert := req.TLS.VerifiedChains[0][0]
+	for _, name := range h.clientNames {
+		if certificateHasName(cert, name) {
+			return &ClientIdentity{Name: name, Subject: cert.Subject.String(), Certificate: cert}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unexpected subject %s", ErrInvalidClientCert, cert.Subject)
+}
+
+// certificateHasName reports whether name is the certificate's common name or one of its DNS SANs
+func certificateHasName(cert *x509.Certificate, name string) bool {
+	if cert.Subject.CommonName == name {
+		return true
+	}
+	for _, n := range cert.DNSNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and its key, signed by parent or self-signed when parent is nil
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, parent *testCert, cn string, sans ...string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Slack Technologies"}},
+		DNSNames:     sans,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// writeCABundle writes the CA certificates to a PEM file and returns its path
+func writeCABundle(t *testing.T, dir string, cas ...*testCert) string {
+	var b bytes.Buffer
+	for _, ca := range cas {
+		pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	}
+	path := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(path, b.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyClient(t *testing.T) {
+	ca := newTestCert(t, nil, "Test CA")
+	tt := []struct {
+		name   string
+		client *testCert
+		names  []string
+		want   string
+	}{
+		{"Slack common name", newTestCert(t, ca, SlackClientName), nil, SlackClientName},
+		{"Slack SAN", newTestCert(t, ca, "Slack", "other.example.com", SlackClientName), nil, SlackClientName},
+		{"Custom name", newTestCert(t, ca, "proxy.example.com"), []string{SlackClientName, "proxy.example.com"}, "proxy.example.com"},
+		{"Unexpected name", newTestCert(t, ca, "evil.example.com"), nil, ""},
+		{"No certificate", nil, nil, ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(WithMutualTLS(tc.names...))
+			r := httptest.NewRequest("POST", basePath, nil)
+			if tc.client != nil {
+				r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tc.client.cert, ca.cert}}}
+			}
+			id, err := s.verifyClient(&Request{Request: r})
+			if tc.want == "" {
+				if !errors.Is(err, ErrInvalidClientCert) {
+					t.Fatalf("Expected the client to be rejected. Got %v", err)
+				}
+				return
+			}
+			if err != nil || id.Name != tc.want || id.Certificate != tc.client.cert {
+				t.Fatalf("Unexpected identity %+v: %v", id, err)
+			}
+		})
+	}
+}
+
+func TestVerifyClientHeader(t *testing.T) {
+	s := New(WithMutualTLSHeader(dnHeader))
+	r := httptest.NewRequest("POST", basePath, nil)
+	r.Header.Set(dnHeader, "CN=platform-tls-client.slack.com,O=Slack Technologies")
+	id, err := s.verifyClient(&Request{Request: r})
+	if err != nil || id.Name != SlackClientName || id.Subject != "CN=platform-tls-client.slack.com,O=Slack Technologies" || id.Certificate != nil {
+		t.Fatalf("Unexpected identity %+v: %v", id, err)
+	}
+}
+
+func TestMutualTLSServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, nil, "Test CA")
+	cfg, err := MutualTLSConfig(writeCABundle(t, dir, ca))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *ClientIdentity
+	s := New(WithSigningSecret(slackSecret), WithMutualTLS())
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		got = ClientIdentityFromContext(req.Context())
+		return nil
+	})
+	srv := httptest.NewUnstartedServer(s)
+	srv.Config.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+	srv.TLS = cfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	send := func(client *testCert) (*http.Response, error) {
+		c := srv.Client()
+		transport := c.Transport.(*http.Transport)
+		transport.TLSClientConfig.Certificates = nil
+		if client != nil {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{client.tlsCertificate()}
+		}
+		transport.CloseIdleConnections()
+		req, _ := http.NewRequest("POST", srv.URL+basePath, bytes.NewBufferString(slashCommandRaw))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addSlackHeaders(slashCommandRaw, req)
+		return c.Do(req)
+	}
+
+	resp, err := send(newTestCert(t, ca, SlackClientName))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got %v %v", resp, err)
+	}
+	if got == nil || got.Name != SlackClientName || got.Certificate == nil {
+		t.Fatalf("Expected the client identity to be passed to the handler. Got %+v", got)
+	}
+
+	if _, err := send(newTestCert(t, newTestCert(t, nil, "Other CA"), SlackClientName)); err == nil {
+		t.Fatalf("Expected a certificate from another CA to fail the handshake")
+	}
+	if _, err := send(nil); err == nil {
+		t.Fatalf("Expected a client without a certificate to fail the handshake")
+	}
+}
+
+func TestMutualTLSConfigErrors(t *testing.T) {
+	if _, err := MutualTLSConfig("/does/not/exist.pem"); err == nil {
+		t.Fatalf("Expected an error for a missing CA bundle")
+	}
+	f, _ := ioutil.TempFile("", "ca")
+	f.WriteString("not a certificate")
+	f.Close()
+	defer os.Remove(f.Name())
+	if _, err := MutualTLSConfig(f.Name()); err == nil {
+		t.Fatalf("Expected an error for a bundle without certificates")
+	}
+}
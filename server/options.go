@@ -0,0 +1,152 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultBasePath is the path Slack requests are received on when WithBasePath is not used
+const DefaultBasePath = "/slack"
+
+// Option configures a SlackHandler created with New
+type Option func(*SlackHandler)
+
+// New returns an initialised SlackHandler configured by opts. Requests are expected on
+// DefaultBasePath and logging is disabled unless options say otherwise
+func New(opts ...Option) *SlackHandler {
+	h := &SlackHandler{
+		DefaultRoute: func(res *Response, req *Request, ctx interface{}) error {
+			res.Text(http.StatusNotFound, "Not found")
+			return nil
+		},
+		SeenStore:   NewMemorySeenStore(DefaultSeenSize, DefaultSeenTTL),
+		Logger:      NopLogger,
+		ReplayStore: NewMemoryReplayStore(DefaultReplaySize),
+		RedactBody:  RedactFields(DefaultRedactedFields...),
+		Metrics:     NopMetrics,
+		basePath:    DefaultBasePath,
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// WithBasePath sets the path Slack sends commands, interactions and events to
+func WithBasePath(p string) Option {
+	return func(h *SlackHandler) {
+		h.basePath = p
+	}
+}
+
+// WithAppToken sets the verification token of the Slack app
+func WithAppToken(token string) Option {
+	return func(h *SlackHandler) {
+		h.appToken = token
+	}
+}
+
+// WithSigningSecret sets the secret used to verify that requests were signed by Slack
+func WithSigningSecret(secret string) Option {
+	return WithSigningSecrets(secret)
+}
+
+// WithSigningSecrets accepts requests signed with any of the secrets, e.g. the new and old
+// secrets while rotating them. The current secret should be first
+func WithSigningSecrets(secrets ...string) Option {
+	return WithSecretProvider(StaticSecrets(secrets))
+}
+
+// WithSecretProvider loads the signing secrets from p for every request, e.g. a FileSecrets
+// which picks up a rotated secret without a restart
+func WithSecretProvider(p SecretProvider) Option {
+	return func(h *SlackHandler) {
+		h.secrets = p
+	}
+}
+
+// WithMutualTLSHeader requires requests to carry the DN of Slack's client certificate in
+// the given header, as set by a proxy which terminates mutual TLS
+func WithMutualTLSHeader(header string) Option {
+	return func(h *SlackHandler) {
+		h.dnHeader = &header
+	}
+}
+
+// WithLogger sets the Logger, logging is disabled by default
+func WithLogger(l Logger) Option {
+	return func(h *SlackHandler) {
+		h.Logger = l
+	}
+}
+
+// WithDefaultRoute sets the handler for requests which do not match any route
+func WithDefaultRoute(f SlackHandlerFunc) Option {
+	return func(h *SlackHandler) {
+		h.DefaultRoute = f
+	}
+}
+
+// WithResponseTimeout sets the deadline given to synchronous handlers
+func WithResponseTimeout(d time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.ResponseTimeout = d
+	}
+}
+
+// WithMaxBodySize rejects requests with a body larger than n bytes
+func WithMaxBodySize(n int64) Option {
+	return func(h *SlackHandler) {
+		h.MaxBodySize = n
+	}
+}
+
+// WithMetrics sets the Metrics which are notified about the requests served
+func WithMetrics(m Metrics) Option {
+	return func(h *SlackHandler) {
+		h.Metrics = m
+	}
+}
+
+// WithSeenStore sets the store used to skip retried events, nil handles every delivery
+func WithSeenStore(s SeenStore) Option {
+	return func(h *SlackHandler) {
+		h.SeenStore = s
+	}
+}
+
+// WithNoRetry responds to events with X-Slack-No-Retry so that Slack does not retry them
+func WithNoRetry() Option {
+	return func(h *SlackHandler) {
+		h.NoRetry = true
+	}
+}
+
+// WithReplayStore sets the store used to reject replayed requests, nil accepts them
+func WithReplayStore(s SeenStore) Option {
+	return func(h *SlackHandler) {
+		h.ReplayStore = s
+	}
+}
+
+// WithValidationWindow sets how old a request may be before it is rejected as stale
+func WithValidationWindow(d time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.ValidationWindow = d
+	}
+}
+
+// WithClockSkew sets how far ahead of our clock a request's timestamp may be
+func WithClockSkew(d time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.ClockSkew = d
+	}
+}
+
+// WithBodyRedactor sets the hook which removes sensitive values from request bodies before
+// they are logged, nil logs them as received
+func WithBodyRedactor(r Redactor) Option {
+	return func(h *SlackHandler) {
+		h.RedactBody = r
+	}
+}
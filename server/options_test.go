@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	s := New(WithSigningSecret(slackSecret))
+	if s.basePath != DefaultBasePath || s.Logger != NopLogger || s.Metrics != NopMetrics || s.SeenStore == nil || s.dnHeader != nil {
+		t.Fatalf("Unexpected defaults: %+v", s)
+	}
+	if resp := performGenericFormRequest(slashCommandRaw, DefaultBasePath, s); resp.StatusCode != 404 {
+		t.Fatalf("Expected the default route to 404. Got %d", resp.StatusCode)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	l := newRecordingLogger()
+	defaultCalled := false
+	s := New(
+		WithBasePath("/hooks/slack"),
+		WithAppToken("TOKEN"),
+		WithSigningSecret(slackSecret),
+		WithMutualTLSHeader(dnHeader),
+		WithLogger(l),
+		WithDefaultRoute(func(res *Response, req *Request, ctx interface{}) error {
+			defaultCalled = true
+			return nil
+		}),
+		WithResponseTimeout(time.Second),
+		WithMaxBodySize(1024),
+		WithMetrics(NopMetrics),
+		WithSeenStore(nil),
+		WithNoRetry(),
+	)
+	if s.basePath != "/hooks/slack" || s.appToken != "TOKEN" || *s.dnHeader != dnHeader || s.ResponseTimeout != time.Second || s.MaxBodySize != 1024 || s.SeenStore != nil || !s.NoRetry {
+		t.Fatalf("Options were not applied: %+v", s)
+	}
+	performGenericFormRequest(slashCommandRaw, "/hooks/slack", s)
+	if !defaultCalled || len(*l.entries) == 0 {
+		t.Fatalf("Expected the default route and logger to be used")
+	}
+}
+
+func TestNewSlackHandlerWrapper(t *testing.T) {
+	s := NewSlackHandler("/hooks/slack", "TOKEN", slackSecret, nil, log, logf, errorLog, errorLogf)
+	if s.basePath != "/hooks/slack" || s.appToken != "TOKEN" || !reflect.DeepEqual(s.secrets, StaticSecrets{slackSecret}) || s.dnHeader != nil || s.ReplayStore != nil {
+		t.Fatalf("Unexpected handler: %+v", s)
+	}
+	if _, ok := s.Logger.(FuncLogger); !ok {
+		t.Fatalf("Expected the log funcs to be adapted. Got %T", s.Logger)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	s := New(WithSigningSecret(slackSecret), WithMaxBodySize(64), WithLogger(FuncLogger{ErrorLogf: errorLogf}))
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	})
+	if resp := performGenericFormRequest(slashCommandRaw, DefaultBasePath, s); resp.StatusCode != 413 {
+		t.Fatalf("Expected a large body to be rejected with a 413. Got %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(logString, "Bad request from slack: request body too large") {
+		t.Fatalf("Unexpected error string: %s", logString)
+	}
+}
+
+// recordingMetrics keeps a line for every measurement
+type recordingMetrics struct {
+	got []string
+}
+
+func (m *recordingMetrics) Handled(rt *Route, d time.Duration, err error) {
+	kind, match := rt.describe()
+	m.got = append(m.got, fmt.Sprintf("handled %s %s %v", kind, match, err))
+}
+
+func (m *recordingMetrics) Rejected(reason string) {
+	m.got = append(m.got, "rejected "+reason)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &recordingMetrics{}
+	s := New(WithSigningSecret(slackSecret), WithMetrics(m))
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return fmt.Errorf("failed")
+	})
+	s.HandleEventCallback("emoji_changed", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	performGenericFormRequest(slashCommandRaw, DefaultBasePath, s)
+	deliver(retriedEvent, 0, s)
+	deliver(retriedEvent, 1, s)
+	performGenericRequest(httptest.NewRequest("POST", DefaultBasePath, nil), s)
+
+	want := "handled command /bob-test failed,handled event emoji_changed <nil>,rejected duplicate,rejected invalid"
+	if got := strings.Join(m.got, ","); got != want {
+		t.Fatalf("Unexpected metrics: %s", got)
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// MatchType controls how the CallbackID, ActionID or EventSubtype of a route is compared
+type MatchType int
+
+// Match types, in order of precedence when more than one route matches a request
+const (
+	ExactMatch  MatchType = iota // The value must be equal, the default
+	PrefixMatch                  // The value must start with the given prefix
+	GlobMatch                    // * matches anything and {name} captures a parameter, e.g. ticket:{id}:close
+	RegexpMatch                  // A regular expression, named groups are captured as parameters
+)
+
+// anyMatch ranks event routes without a subtype, which match every subtype of their event
+const anyMatch MatchType = RegexpMatch + 1
+
+// ErrUnsupportedPattern is returned when a pattern is given for a route which can only be matched exactly
+var ErrUnsupportedPattern = errors.New("patterns are only supported for callback IDs, action IDs and event subtypes")
+
+// MatchPrefix matches the CallbackID, ActionID or EventSubtype of a route as a prefix
+func MatchPrefix() RouteOption {
+	return func(r *Route) {
+		r.Match = PrefixMatch
+	}
+}
+
+// MatchGlob matches the CallbackID, ActionID or EventSubtype of a route as a glob
+// e.g. HandleViewSubmission("ticket:{id}:close", f, MatchGlob())
+func MatchGlob() RouteOption {
+	return func(r *Route) {
+		r.Match = GlobMatch
+	}
+}
+
+// MatchRegexp matches the CallbackID, ActionID or EventSubtype of a route as a regular expression
+// e.g. HandleBlockAction(`^vote_(?P<choice>yes|no)$`, f, MatchRegexp())
+func MatchRegexp() RouteOption {
+	return func(r *Route) {
+		r.Match = RegexpMatch
+	}
+}
+
+// ForSubtype restricts an event route to events with the given subtype, e.g. message_changed
+func ForSubtype(subtype string) RouteOption {
+	return func(r *Route) {
+		r.EventSubtype = subtype
+	}
+}
+
+// RouteParams returns the parameters captured by the pattern of the matched route
+func RouteParams(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(paramsKey).(map[string]string)
+	return params
+}
+
+// Param returns a parameter captured by the pattern of the matched route, or an empty string
+func (r *Request) Param(name string) string {
+	return RouteParams(r.Context())[name]
+}
+
+// pattern returns the value of the route which is matched using its MatchType
+func (r *Route) pattern() string {
+	switch {
+	case r.EventType != "":
+		return r.EventSubtype
+	case r.ActionID != "":
+		return r.ActionID
+	default:
+		return r.CallbackID
+	}
+}
+
+// compile prepares the pattern of a route for matching
+func (r *Route) compile() error {
+	if r.Match == ExactMatch {
+		return nil
+	}
+	if r.Command != "" || r.InteractionType == "" && r.EventType == "" || r.pattern() == "" {
+		return ErrUnsupportedPattern
+	}
+	expr := r.pattern()
+	if r.Match == GlobMatch {
+		expr = globToRegexp(expr)
+	}
+	var err error
+	if r.Match != PrefixMatch {
+		r.re, err = regexp.Compile(expr)
+	}
+	return err
+}
+
+// matchValue compares the pattern of the route against the value from a request
+// The rank of the match and any captured parameters are returned with it
+func (r *Route) matchValue(v string) (MatchType, map[string]string, bool) {
+	switch r.Match {
+	case PrefixMatch:
+		return r.Match, nil, strings.HasPrefix(v, r.pattern())
+	case GlobMatch, RegexpMatch:
+		m := r.re.FindStringSubmatch(v)
+		if m == nil {
+			return r.Match, nil, false
+		}
+		params := map[string]string{}
+		for i, name := range r.re.SubexpNames() {
+			if name != "" {
+				params[name] = m[i]
+			}
+		}
+		return r.Match, params, true
+	default:
+		if r.EventType != "" && r.EventSubtype == "" {
+			return anyMatch, nil, true
+		}
+		return ExactMatch, nil, r.pattern() == v
+	}
+}
+
+// globToRegexp converts a glob such as ticket:{id}:* in to an anchored regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for len(glob) > 0 {
+		switch {
+		case glob[0] == '*':
+			b.WriteString(".*")
+			glob = glob[1:]
+		case glob[0] == '{' && strings.Contains(glob, "}"):
+			end := strings.Index(glob, "}")
+			b.WriteString("(?P<" + glob[1:end] + ">.+?)")
+			glob = glob[end+1:]
+		default:
+			end := strings.IndexAny(glob[1:], "*{") + 1
+			if end == 0 {
+				end = len(glob)
+			}
+			b.WriteString(regexp.QuoteMeta(glob[:end]))
+			glob = glob[end:]
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// bestMatch is the highest precedence route found so far while matching a request
+type bestMatch struct {
+	route  *Route
+	rank   MatchType
+	params map[string]string
+}
+
+// consider replaces the best match if rt matches v with a higher precedence
+// Routes of the same rank are matched in the order in which they were registered
+func (b *bestMatch) consider(rt *Route, v string) {
+	rank, params, ok := rt.matchValue(v)
+	if ok && (b.route == nil || rank < b.rank) {
+		b.route, b.rank, b.params = rt, rank, params
+	}
+}
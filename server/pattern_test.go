@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// recordMatch returns a handler which records its name and the parameters captured by its route
+func recordMatch(name string, got *string) SlackHandlerFunc {
+	return func(res *Response, req *Request, ctx interface{}) error {
+		*got = fmt.Sprintf("%s %v", name, RouteParams(req.Context()))
+		return nil
+	}
+}
+
+func TestInteractionPatterns(t *testing.T) {
+	var got string
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleInteractionCallback("dialog_submission", `^ticket:(?P<id>\d+):(?P<verb>\w+)$`, recordMatch("regexp", &got), MatchRegexp())
+	s.HandleInteractionCallback("dialog_submission", "ticket:{id}:close", recordMatch("glob", &got), MatchGlob())
+	s.HandleInteractionCallback("dialog_submission", "ticket:", recordMatch("prefix", &got), MatchPrefix())
+	s.HandleInteractionCallback("dialog_submission", "ticket:1:close", recordMatch("exact", &got))
+	s.HandleBlockAction(`^vote_(?P<choice>yes|no)$`, recordMatch("vote", &got), MatchRegexp())
+
+	tt := []struct {
+		payload, want string
+	}{
+		{`{"type":"dialog_submission","callback_id":"ticket:1:close"}`, "exact map[]"},
+		{`{"type":"dialog_submission","callback_id":"ticket:1234:reopen"}`, "prefix map[]"},
+		{`{"type":"view_submission","view":{"callback_id":"ticket:1234:close"}}`, ""},
+		{`{"type":"block_actions","actions":[{"type":"button","action_id":"vote_no","block_id":"poll"}]}`, "vote map[choice:no]"},
+		{`{"type":"block_actions","actions":[{"type":"button","action_id":"vote_maybe","block_id":"poll"}]}`, ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.payload, func(t *testing.T) {
+			got = ""
+			performGenericFormRequest(interactionRaw(tc.payload), basePath, s)
+			if got != tc.want {
+				t.Fatalf("Expected %q to be handled. Got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPatternPrecedence(t *testing.T) {
+	var got string
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleInteractionCallback("dialog_submission", `^ticket:(?P<id>\d+):close$`, recordMatch("regexp", &got), MatchRegexp())
+	s.HandleInteractionCallback("dialog_submission", "ticket:{id}:close", recordMatch("glob", &got), MatchGlob())
+	s.HandleInteractionCallback("dialog_submission", "*:close", recordMatch("second glob", &got), MatchGlob())
+
+	performGenericFormRequest(interactionRaw(`{"type":"dialog_submission","callback_id":"ticket:1234:close"}`), basePath, s)
+	if got != "glob map[id:1234]" {
+		t.Fatalf("Expected the first glob to win over the regexp. Got %q", got)
+	}
+}
+
+func TestEventSubtypes(t *testing.T) {
+	var got string
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleEventCallback("message", recordMatch("any", &got))
+	s.HandleEventCallback("message", recordMatch("changed", &got), ForSubtype("message_changed"))
+	s.HandleEventCallback("message", recordMatch("bot", &got), ForSubtype("bot_{kind}"), MatchGlob())
+
+	tt := []struct {
+		subtype, want string
+	}{
+		{"", "any map[]"},
+		{"message_changed", "changed map[]"},
+		{"bot_message", "bot map[kind:message]"},
+		{"channel_join", "any map[]"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.subtype, func(t *testing.T) {
+			got = ""
+			performGenericJsonRequest(fmt.Sprintf(`{"event":{"type":"message","subtype":"%s","text":"hi"},"type":"event_callback"}`, tc.subtype), basePath, s)
+			if got != tc.want {
+				t.Fatalf("Expected %q to be handled. Got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	if err := s.HandleCommand("/bob", h, MatchPrefix()); !errors.Is(err, ErrUnsupportedPattern) {
+		t.Fatalf("Expected command patterns to be rejected. Got: %v", err)
+	}
+	if err := s.HandleEventCallback("message", h, MatchGlob()); !errors.Is(err, ErrUnsupportedPattern) {
+		t.Fatalf("Expected an event pattern without a subtype to be rejected. Got: %v", err)
+	}
+	if err := s.HandleBlockAction("vote_(", h, MatchRegexp()); err == nil || err.Error() != "block_actions vote_( (regexp): error parsing regexp: missing closing ): `vote_(`" {
+		t.Fatalf("Expected an invalid regexp to be rejected. Got: %v", err)
+	}
+	if err := s.HandleBlockAction("vote_", h, MatchPrefix()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := s.HandleBlockAction("vote_", h); err != nil {
+		t.Fatalf("An exact route should not conflict with a pattern. Got: %s", err)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tt := map[string]string{
+		"ticket:{id}:close": `^ticket:(?P<id>.+?):close$`,
+		"vote.*":            `^vote\..*$`,
+		"{a}-{b}":           `^(?P<a>.+?)-(?P<b>.+?)$`,
+		"{unterminated":     `^\{unterminated$`,
+	}
+	for glob, want := range tt {
+		if got := globToRegexp(glob); got != want {
+			t.Errorf("Unexpected regexp for %s: %s", glob, got)
+		}
+	}
+}
@@ -1,37 +1,49 @@
 package server
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/nlopes/slack/slackevents"
+	"github.com/slack-go/slack/slackevents"
 
-	"github.com/nlopes/slack"
+	"github.com/slack-go/slack"
 )
 
 // Request wraps http.Request
 type Request struct {
 	*http.Request
 	payload *slack.InteractionCallback
+	body    *requestBody
 }
 
 // Validate the request comes from Slack
 func (r *Request) Validate(secret string, dnHeader *string) error {
+	_, err := r.ValidateSecrets([]string{secret}, dnHeader)
+	return err
+}
+
+// ValidateSecrets checks the request comes from Slack, accepting a signature made with any of
+// the secrets. It returns the index of the secret which matched
+func (r *Request) ValidateSecrets(secrets []string, dnHeader *string) (int, error) {
+	return r.verify(secrets, dnHeader, DefaultValidationWindow, DefaultClockSkew, time.Now())
+}
+
+// verify checks the request was signed with one of the secrets no longer than window before
+// now, allowing its timestamp to be up to skew ahead of our clock
+func (r *Request) verify(secrets []string, dnHeader *string, window, skew time.Duration, now time.Time) (int, error) {
 	// If a dnHeader has been provided, check that the header contains the slack CN
 	if dnHeader != nil {
 		slackDNHeader := r.Header.Get(*dnHeader)
 		if !strings.Contains(slackDNHeader, "platform-tls-client.slack.com") {
-			return fmt.Errorf("invalid CN in DN header")
+			return -1, ErrInvalidDN
 		}
 	}
 
@@ -40,34 +52,45 @@ func (r *Request) Validate(secret string, dnHeader *string) error {
 
 	// Abort if timestamp is invalid
 	if err != nil {
-		return fmt.Errorf("invalid timestamp sent from slack: %s", err)
+		return -1, fmt.Errorf("%w: %s", ErrInvalidTimestamp, err)
 	}
 
-	// Abort if timestamp is stale (older than 5 minutes)
-	now := int64(time.Now().Unix())
-	if (now - slackTimestamp) > (60 * 5) {
-		return fmt.Errorf("stale timestamp sent from slack: %s", err)
+	// Abort if timestamp is stale, or too far in the future to be explained by clock skew
+	age := now.Sub(time.Unix(slackTimestamp, 0))
+	if age > window {
+		return -1, fmt.Errorf("%w: sent %s ago", ErrStaleTimestamp, age.Truncate(time.Second))
+	}
+	if -age > skew {
+		return -1, fmt.Errorf("%w: sent %s ahead", ErrSkewedTimestamp, (-age).Truncate(time.Second))
 	}
 
 	// Abort if request body is invalid
-	body, err := ioutil.ReadAll(r.Body)
+	body, err := r.readBody(0)
 	if err != nil {
-		return fmt.Errorf("invalid request body sent from slack: %s", err)
+		return -1, err
 	}
-	slackBody := string(body)
 
-	// Abort if the signature does not correspond to the signing secret
-	slackBaseStr := []byte(fmt.Sprintf("v0:%d:%s", slackTimestamp, slackBody))
-	slackSignature := r.Header.Get("X-Slack-Signature")
-	sec := hmac.New(sha256.New, []byte(secret))
-	sec.Write(slackBaseStr)
-	mySig := fmt.Sprintf("v0=%s", []byte(hex.EncodeToString(sec.Sum(nil))))
-	if mySig != slackSignature {
-		return errors.New("invalid signature sent from slack")
+	if len(secrets) == 0 {
+		return -1, ErrNoSigningSecrets
 	}
-	// All good! The request is valid
-	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-	return nil
+
+	// Abort if the signature does not correspond to any of the signing secrets
+	slackSignatureHeader := r.Header.Get("X-Slack-Signature")
+	slackSignature, err := hex.DecodeString(strings.TrimPrefix(slackSignatureHeader, "v0="))
+	if err != nil || !strings.HasPrefix(slackSignatureHeader, "v0=") {
+		return -1, ErrInvalidSignature
+	}
+	slackBaseStr := []byte(fmt.Sprintf("v0:%d:%s", slackTimestamp, body))
+	for i, secret := range secrets {
+		sec := hmac.New(sha256.New, []byte(secret))
+		sec.Write(slackBaseStr)
+		// Compare in constant time so the signature can not be guessed from response times
+		if hmac.Equal(sec.Sum(nil), slackSignature) {
+			// All good! The request is valid
+			return i, nil
+		}
+	}
+	return -1, ErrInvalidSignature
 }
 
 // InteractionCallbackPayload returns the parsed payload for an interaction if it exists and is valid
@@ -80,7 +103,7 @@ func (r *Request) InteractionCallbackPayload() (*slack.InteractionCallback, erro
 	if r.payload.Type == "" {
 		errs = append(errs, "Missing value for 'type' key")
 	}
-	if r.payload.CallbackID == "" {
+	if r.payload.CallbackID == "" && requiresCallbackID(r.payload.Type) {
 		errs = append(errs, "Missing value for 'callback_id' key")
 	}
 	if len(errs) > 0 {
@@ -106,6 +129,18 @@ func (r *Request) EventAPIEvent(body []byte) (*slackevents.EventsAPIEvent, error
 	return &eventsAPIEvent, nil
 }
 
+// requiresCallbackID reports whether an interaction type carries a top level callback_id
+// Block Kit interactions identify themselves by action_id or view.callback_id instead
+func requiresCallbackID(t slack.InteractionType) bool {
+	switch t {
+	case slack.InteractionTypeBlockActions, slack.InteractionTypeBlockSuggestion,
+		slack.InteractionTypeViewSubmission, slack.InteractionTypeViewClosed:
+		return false
+	default:
+		return true
+	}
+}
+
 func (r *Request) parseInteractionPayload() error {
 	var payload slack.InteractionCallback
 	j := r.Form.Get("payload")
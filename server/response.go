@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,3 +18,10 @@ func (r *Response) Text(code int, body string) {
 	r.WriteHeader(code)
 	io.WriteString(r, fmt.Sprintf("%s\n", body))
 }
+
+// JSON is a convenience method for sending a JSON response
+func (r *Response) JSON(code int, v interface{}) error {
+	r.Header().Set("Content-Type", "application/json")
+	r.WriteHeader(code)
+	return json.NewEncoder(r).Encode(v)
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/slack-go/slack"
+)
+
+// Errors returned when registering a route which could never be served
+var (
+	ErrDuplicateRoute      = errors.New("route is already registered")
+	ErrNoHandler           = errors.New("route has no handler")
+	ErrPathOutsideBasePath = errors.New("path is outside of the base path")
+	ErrPathInsideBasePath  = errors.New("path is inside the base path")
+)
+
+// RouteError describes why a route could not be registered
+type RouteError struct {
+	Route *Route
+	Err   error
+}
+
+func (e *RouteError) Error() string {
+	kind, match := e.Route.describe()
+	return fmt.Sprintf("%s %s: %s", kind, match, e.Err)
+}
+
+// Unwrap allows the reason to be checked with errors.Is
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// Routes returns the registered routes in the order they are matched
+func (h *SlackHandler) Routes() []*Route {
+	return append([]*Route(nil), h.routes...)
+}
+
+// RouteTable describes the registered routes as a table, suitable for logging at startup
+// The subcommands of a CommandRouter are listed after its command
+func (h *SlackHandler) RouteTable() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tMATCH\tPATH\tASYNC")
+	for _, rt := range h.routes {
+		kind, match := rt.describe()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", kind, match, rt.Path, rt.Async)
+		if rt.router != nil {
+			for _, s := range rt.router.subcommands {
+				fmt.Fprintf(w, "subcommand\t%s %s\t%s\t%t\n", rt.Command, s.Name, rt.Path, rt.Async)
+			}
+		}
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// slackRoute reports whether the route is matched on the content of a request to the BasePath
+func (r *Route) slackRoute() bool {
+	return r.Command != "" || r.InteractionType != "" || r.EventType != ""
+}
+
+// describe returns the kind of route and what it matches on
+func (r *Route) describe() (string, string) {
+	kind, match := r.describeExact()
+	switch r.Match {
+	case PrefixMatch:
+		match += "* (prefix)"
+	case GlobMatch:
+		match += " (glob)"
+	case RegexpMatch:
+		match += " (regexp)"
+	}
+	return kind, match
+}
+
+func (r *Route) describeExact() (string, string) {
+	switch {
+	case r.Command != "":
+		return "command", r.Command
+	case r.InteractionType == string(slack.InteractionTypeBlockActions) && r.BlockID != "":
+		return r.InteractionType, r.BlockID + "/" + r.ActionID
+	case r.InteractionType == string(slack.InteractionTypeBlockActions):
+		return r.InteractionType, r.ActionID
+	case r.InteractionType != "":
+		return r.InteractionType, r.CallbackID
+	case r.EventType != "" && r.EventSubtype != "":
+		return "event", r.EventType + "/" + r.EventSubtype
+	case r.EventType != "":
+		return "event", r.EventType
+	default:
+		return "path", r.Path
+	}
+}
+
+// conflicts reports whether both routes would match the same requests
+func (r *Route) conflicts(o *Route) bool {
+	if r.slackRoute() != o.slackRoute() {
+		return false
+	}
+	if !r.slackRoute() {
+		return r.Path == o.Path
+	}
+	return r.Command == o.Command && r.InteractionType == o.InteractionType && r.EventType == o.EventType &&
+		r.CallbackID == o.CallbackID && r.ActionID == o.ActionID && r.BlockID == o.BlockID &&
+		r.EventSubtype == o.EventSubtype && r.Match == o.Match
+}
+
+// validate checks that a route can be served and does not clash with one already registered
+func (h *SlackHandler) validate(r *Route) error {
+	if r.Handler == nil {
+		return &RouteError{Route: r, Err: ErrNoHandler}
+	}
+	if r.slackRoute() && !strings.HasPrefix(r.Path, h.basePath) {
+		return &RouteError{Route: r, Err: ErrPathOutsideBasePath}
+	}
+	// Requests under the base path are always matched as Slack requests, never on their path
+	if !r.slackRoute() && strings.HasPrefix(r.Path, h.basePath) {
+		return &RouteError{Route: r, Err: ErrPathInsideBasePath}
+	}
+	if err := r.compile(); err != nil {
+		return &RouteError{Route: r, Err: err}
+	}
+	for _, rt := range h.routes {
+		if r.conflicts(rt) {
+			return &RouteError{Route: r, Err: ErrDuplicateRoute}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRouteValidation(t *testing.T) {
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	}
+	outside := func(r *Route) {
+		r.Path = "/elsewhere"
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	for _, err := range []error{
+		s.HandleCommand("/bob-test", h),
+		s.HandleViewSubmission("HelpRequest", h),
+		s.HandleBlockAction("claim", h),
+		s.HandleBlockAction("claim", h, ForBlock("ticket")),
+		s.HandleEventCallback("emoji_changed", h),
+		s.HandlePath("/foo", h),
+	} {
+		if err != nil {
+			t.Fatalf("Unexpected error registering a route: %s", err)
+		}
+	}
+
+	tt := []struct {
+		name string
+		err  error
+		want error
+		msg  string
+	}{
+		{"duplicate command", s.HandleCommand("/bob-test", h), ErrDuplicateRoute, "command /bob-test: route is already registered"},
+		{"duplicate callback", s.HandleViewSubmission("HelpRequest", h), ErrDuplicateRoute, "view_submission HelpRequest: route is already registered"},
+		{"duplicate action", s.HandleBlockAction("claim", h, ForBlock("ticket")), ErrDuplicateRoute, "block_actions ticket/claim: route is already registered"},
+		{"duplicate event", s.HandleEventCallback("emoji_changed", h), ErrDuplicateRoute, "event emoji_changed: route is already registered"},
+		{"duplicate path", s.HandlePath("/foo", h), ErrDuplicateRoute, "path /foo: route is already registered"},
+		{"no handler", s.HandleCommand("/nothing", nil), ErrNoHandler, "command /nothing: route has no handler"},
+		{"no typed handler", s.HandleCommandFunc("/nothing", nil), ErrNoHandler, "command /nothing: route has no handler"},
+		{"outside base path", s.HandleShortcut("raise", h, outside), ErrPathOutsideBasePath, "shortcut raise: path is outside of the base path"},
+		{"inside base path", s.HandlePath(basePath+"/health", h), ErrPathInsideBasePath, "path /slack/health: path is inside the base path"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if !errors.Is(tc.err, tc.want) {
+				t.Fatalf("Expected %v. Got: %v", tc.want, tc.err)
+			}
+			if tc.err.Error() != tc.msg {
+				t.Fatalf("Unexpected error message: %s", tc.err)
+			}
+		})
+	}
+	if len(s.Routes()) != 6 {
+		t.Fatalf("Invalid routes should not be registered. Got %d routes", len(s.Routes()))
+	}
+}
+
+func TestRouteTable(t *testing.T) {
+	h := func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommand("/bob-test", h)
+	s.HandleBlockAction("claim", h, Async())
+	s.HandlePath("/foo", h)
+	cr, _ := s.HandleSubcommands("/help-me")
+	cr.Handle("status", "Show a ticket", nil)
+
+	want := strings.Join([]string{
+		"TYPE           MATCH            PATH    ASYNC",
+		"command        /bob-test        /slack  false",
+		"block_actions  claim            /slack  true",
+		"path           /foo             /foo    false",
+		"command        /help-me         /slack  false",
+		"subcommand     /help-me status  /slack  false",
+	}, "\n")
+	if got := s.RouteTable(); got != want {
+		t.Fatalf("Unexpected route table:\n%s", got)
+	}
+
+	routes := s.Routes()
+	routes[0] = nil
+	if s.Routes()[0] == nil {
+		t.Fatalf("Routes should return a copy of the registered routes")
+	}
+}
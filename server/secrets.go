@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoSigningSecrets is returned when a request is validated without any signing secrets
+var ErrNoSigningSecrets = errors.New("no signing secrets configured")
+
+// SecretProvider supplies the secrets used to verify that requests were signed by Slack
+// Secrets are tried in order, so list the current secret first while rotating it
+type SecretProvider interface {
+	Secrets() ([]string, error)
+}
+
+// StaticSecrets is a SecretProvider for a fixed list of secrets
+type StaticSecrets []string
+
+// Secrets satisfies the SecretProvider interface
+func (s StaticSecrets) Secrets() ([]string, error) {
+	return s, nil
+}
+
+// FileSecrets is a SecretProvider which reads secrets from a file, one per line. Blank lines
+// and lines starting with # are ignored. The file is read again whenever it is modified, so
+// secrets can be rotated without a restart
+type FileSecrets struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	secrets []string
+}
+
+// NewFileSecrets returns a FileSecrets reading from path. The file is read on first use
+func NewFileSecrets(path string) *FileSecrets {
+	return &FileSecrets{path: path}
+}
+
+// Secrets satisfies the SecretProvider interface
+func (f *FileSecrets) Secrets() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing secrets: %s", err)
+	}
+	if f.secrets != nil && fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return f.secrets, nil
+	}
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing secrets: %s", err)
+	}
+	secrets := []string{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			secrets = append(secrets, line)
+		}
+	}
+	f.secrets, f.modTime, f.size = secrets, fi.ModTime(), fi.Size()
+	return f.secrets, nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecretRotation(t *testing.T) {
+	tt := []struct {
+		name    string
+		secrets []string
+		code    int
+		log     string
+	}{
+		{"Current secret", []string{slackSecret, "old_secret"}, 200, "Request signed with signing secret 1 of 2"},
+		{"Old secret", []string{"new_secret", slackSecret}, 200, "Request signed with signing secret 2 of 2"},
+		{"No match", []string{"new_secret", "old_secret"}, 400, "Bad request from slack: invalid signature sent from slack"},
+		{"No secrets", nil, 400, "Bad request from slack: no signing secrets configured"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newRecordingLogger()
+			s := New(WithSigningSecrets(tc.secrets...), WithLogger(l))
+			s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+				return nil
+			})
+			resp := performGenericFormRequest(slashCommandRaw, DefaultBasePath, s)
+			if resp.StatusCode != tc.code {
+				t.Fatalf("Expected a %d status. Got %d", tc.code, resp.StatusCode)
+			}
+			if len(*l.entries) == 0 || !strings.Contains((*l.entries)[0], tc.log) {
+				t.Fatalf("Unexpected log entries: %v", *l.entries)
+			}
+		})
+	}
+}
+
+func TestOldSecretIsLoggedAsInfo(t *testing.T) {
+	l := newRecordingLogger()
+	s := New(WithSigningSecrets("new_secret", slackSecret), WithLogger(l))
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	performGenericFormRequest(slashCommandRaw, DefaultBasePath, s)
+	if (*l.entries)[0] != "INFO Request signed with signing secret 2 of 2 path=/slack signing_secret=2" {
+		t.Fatalf("Unexpected log entry: %s", (*l.entries)[0])
+	}
+}
+
+func TestMalformedSignature(t *testing.T) {
+	for _, sig := range []string{"", "v0=not-hex", "v1=abcdef"} {
+		req := httptest.NewRequest("POST", basePath, bytes.NewBufferString("text"))
+		addSlackHeaders("text", req)
+		req.Header.Set("X-Slack-Signature", sig)
+		r := &Request{Request: req}
+		if _, err := r.ValidateSecrets([]string{slackSecret}, nil); err == nil || err.Error() != "invalid signature sent from slack" {
+			t.Fatalf("Expected %q to be rejected. Got %v", sig, err)
+		}
+	}
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+	write := func(content string, mod time.Time) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mod, mod)
+	}
+
+	f := NewFileSecrets(path)
+	if _, err := f.Secrets(); err == nil {
+		t.Fatalf("Expected an error for a missing file")
+	}
+
+	now := time.Now()
+	write("# Rotated 2026-10-01\nnew_secret\n\n  old_secret  \n", now)
+	got, err := f.Secrets()
+	if err != nil || !reflect.DeepEqual(got, []string{"new_secret", "old_secret"}) {
+		t.Fatalf("Unexpected secrets: %v %v", got, err)
+	}
+
+	write("new_secret\n", now.Add(time.Second))
+	got, _ = f.Secrets()
+	if !reflect.DeepEqual(got, []string{"new_secret"}) {
+		t.Fatalf("Expected the file to be reloaded. Got %v", got)
+	}
+}
@@ -1,22 +1,21 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"github.com/nlopes/slack"
-	"github.com/nlopes/slack/slackevents"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
 	"io/ioutil"
 	"net/http"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
-// LogFunc is an abstraction that allows using any external logger with a Print signature
-// Set to nil to disable logging completely
-type LogFunc func(...interface{})
-
-// LogfFunc is an abstraction that allows using any external logger with a Printf signature
-// Set to nil to disable logging completely
-type LogfFunc func(string, ...interface{})
-
 // SlackHandlerFunc is an http.HandlerFunc which can return an error and has a context
 // Context varies depending on the request type and is for injecting arbitrary data in
 // at routing time
@@ -25,71 +24,104 @@ type SlackHandlerFunc func(res *Response, req *Request, ctx interface{}) error
 // Route is a handler which is invoked when a path is matched
 type Route struct {
 	CallbackID, Path, Command, InteractionType, EventType string
+	ActionID, BlockID, EventSubtype                       string
+	Match                                                 MatchType // How the CallbackID, ActionID or EventSubtype is compared
 	Handler                                               SlackHandlerFunc
+	Middleware                                            []Middleware
+	Async                                                 bool
+	// Description, Usage and Examples document the route in the generated help
+	Description, Usage string
+	Examples           []string
+	router             *CommandRouter
+	re                 *regexp.Regexp
 }
 
 // SlackHandler is a function executed when a route is invoked
 type SlackHandler struct {
-	Log          LogFunc
-	Logf         LogfFunc
-	ErrorLog     LogFunc
-	ErrorLogf    LogfFunc
-	Routes       []*Route
-	DefaultRoute SlackHandlerFunc
-	basePath     string
-	appToken     string
-	secretToken  string
-	dnHeader     *string	// Used for Mutual TLS
+	Logger           Logger // Defaults to NopLogger when nil
+	DefaultRoute     SlackHandlerFunc
+	AsyncWorkers     int           // Number of workers executing Async routes, defaults to DefaultAsyncWorkers
+	ResponseTimeout  time.Duration // Deadline given to synchronous handlers, defaults to DefaultResponseTimeout
+	ErrorMessage     string        // Shown to the user when a handler fails, defaults to DefaultErrorMessage
+	SeenStore        SeenStore     // Records event IDs so retries are skipped, set to nil to handle every delivery
+	NoRetry          bool          // Respond to events with X-Slack-No-Retry so Slack does not retry them
+	MaxBodySize      int64         // Requests with a larger body are rejected, no limit when 0
+	Metrics          Metrics       // Defaults to NopMetrics when nil
+	RedactBody       Redactor      // Applied to request bodies before they are logged, nil logs them as received
+	ReplayStore      SeenStore     // Records request signatures so they are only accepted once, nil allows replays
+	ValidationWindow time.Duration // How old a request may be, defaults to DefaultValidationWindow
+	ClockSkew        time.Duration // How far in the future a request may be, defaults to DefaultClockSkew
+	routes           []*Route
+	hearers          []*hearer
+	hearsRegistered  bool // Whether the message and app_mention routes have been added for hearers
+	basePath         string
+	appToken         string
+	secrets          SecretProvider
+	dnHeader         *string  // Used for Mutual TLS terminated by a proxy
+	clientNames      []string // Used for Mutual TLS terminated by the handler
+	middleware       []Middleware
+	pool             *workerPool
+	poolOnce         sync.Once
 }
 
-// NewSlackHandler returns an initialised SlackHandler
+// NewSlackHandler returns an initialised SlackHandler which logs to the given functions
+// through a FuncLogger. Any of them may be nil. New is preferred for new code
+// Replayed requests are accepted, as they always have been, unless a ReplayStore is set
 func NewSlackHandler(basePath, appToken, secretToken string, dnHeader *string, l LogFunc, lf LogfFunc, el LogFunc, elf LogfFunc) *SlackHandler {
-	return &SlackHandler{
-		DefaultRoute: func(res *Response, req *Request, ctx interface{}) error {
-			res.Text(http.StatusNotFound, "Not found")
-			return nil
-		},
-		Log:         l,
-		Logf:        lf,
-		ErrorLog:    el,
-		ErrorLogf:   elf,
-		basePath:    basePath,
-		appToken:    appToken,
-		secretToken: secretToken,
-		dnHeader:    dnHeader,
+	opts := []Option{
+		WithBasePath(basePath),
+		WithAppToken(appToken),
+		WithSigningSecret(secretToken),
+		WithLogger(FuncLogger{Log: l, Logf: lf, ErrorLog: el, ErrorLogf: elf}),
 	}
+	if dnHeader != nil {
+		opts = append(opts, WithMutualTLSHeader(*dnHeader))
+	}
+	h := New(opts...)
+	h.ReplayStore = nil
+	return h
 }
 
 // HandleInteractionCallback registers a handler to be executed when a specific
 // InteractionType / CallbackID pair is present in the request
-func (h *SlackHandler) HandleInteractionCallback(it, cid string, f SlackHandlerFunc) {
+// The handler is passed a *slack.InteractionCallback as context
+func (h *SlackHandler) HandleInteractionCallback(it, cid string, f SlackHandlerFunc, opts ...RouteOption) error {
 	r := &Route{Path: h.basePath, CallbackID: cid, InteractionType: it, Handler: f}
-	h.handle(r)
+	return h.handle(r, opts)
 }
 
 // HandleEventCallback registers a handler to be executed when a specific
 // EventsAPICallbackEvent type is present in the request
-func (h *SlackHandler) HandleEventCallback(et string, f SlackHandlerFunc) {
+// The handler is passed a *slackevents.EventsAPIEvent as context
+func (h *SlackHandler) HandleEventCallback(et string, f SlackHandlerFunc, opts ...RouteOption) error {
 	r := &Route{Path: h.basePath, EventType: et, Handler: f}
-	h.handle(r)
+	return h.handle(r, opts)
 }
 
 // HandleCommand registers a handler to be executed when a slash command
-// request is sent to the BasePath
-func (h *SlackHandler) HandleCommand(c string, f SlackHandlerFunc) {
+// request is sent to the BasePath. The handler is passed a slack.SlashCommand as context
+func (h *SlackHandler) HandleCommand(c string, f SlackHandlerFunc, opts ...RouteOption) error {
 	r := &Route{Path: h.basePath, Command: c, Handler: f}
-	h.handle(r)
+	return h.handle(r, opts)
 }
 
-// HandlePath registers handlers for specific paths
-func (h *SlackHandler) HandlePath(p string, f SlackHandlerFunc) {
+// HandlePath registers handlers for specific paths outside of the BasePath
+// A path under the BasePath could never be served, so it is rejected with ErrPathInsideBasePath
+func (h *SlackHandler) HandlePath(p string, f SlackHandlerFunc, opts ...RouteOption) error {
 	r := &Route{Path: p, Handler: f}
-	h.handle(r)
+	return h.handle(r, opts)
 }
 
-func (h *SlackHandler) handle(r *Route) {
-	// TODO: validate no duplicates
-	h.Routes = append(h.Routes, r)
+// handle applies the options to a route and registers it if it is valid
+func (h *SlackHandler) handle(r *Route, opts []RouteOption) error {
+	for _, o := range opts {
+		o(r)
+	}
+	if err := h.validate(r); err != nil {
+		return err
+	}
+	h.routes = append(h.routes, r)
+	return nil
 }
 
 // ServeHTTP satisfies http.Handler interface
@@ -97,34 +129,68 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	req := &Request{Request: r}
 	res := &Response{w}
 
-	// Generic serve function which captures and logs handler errors
-	serve := func(f SlackHandlerFunc, ctx interface{}) {
-		if err := f(res, req, ctx); err != nil {
-			h.ErrorLogf("HTTP handler error: %s", err)
-			if b, bodyErr := ioutil.ReadAll(r.Body); bodyErr == nil {
-				if len(b) > 0 {
-					h.ErrorLogf("Request body: %s", string(b), nil)
-				}
-			}
-		}
+	// The body is read once, up to the MaxBodySize, and kept in the Request
+	if _, err := req.readBody(h.MaxBodySize); errors.Is(err, ErrBodyTooLarge) {
+		h.metrics().Rejected(RejectedTooLarge)
+		h.logger().Error(fmt.Sprintf("Bad request from slack: %s", err), Fields{"path": r.URL.Path})
+		res.Text(http.StatusRequestEntityTooLarge, "request body too large")
+		return
 	}
 
 	// If the request did not look like it came from slack, 400 and abort
-	if err := req.Validate(h.secretToken, h.dnHeader); err != nil {
-		h.ErrorLogf("Bad request from slack: %s", err)
+	id, err := h.validateRequest(req)
+	if err != nil {
+		h.metrics().Rejected(rejectionReason(err))
+		h.logger().Error(fmt.Sprintf("Bad request from slack: %s", err), Fields{"path": r.URL.Path})
 		res.Text(400, "invalid slack request")
 		return
 	}
+	if id != nil {
+		req = req.withContext(context.WithValue(req.Context(), clientIdentityKey, id))
+	}
+	h.route(res, req)
+}
+
+// route dispatches a request, which is known to have come from Slack, to the matching route
+func (h *SlackHandler) route(res *Response, req *Request) {
+	req = req.withContext(withRequestID(req.Context(), ""))
+	w, r := res.ResponseWriter, req.Request
+	log := h.logger().WithFields(Fields{"request_id": RequestID(r.Context())})
+
+	// Generic serve function which captures and logs handler errors
+	// The matched route, payload and any parameters captured by the route are added to the request context
+	serve := func(rt *Route, ctx interface{}, params map[string]string) {
+		values := context.WithValue(context.WithValue(r.Context(), routeKey, rt), payloadKey, ctx)
+		values = context.WithValue(values, paramsKey, params)
+		values = context.WithValue(values, loggerKey, h.logger().WithFields(requestFields(r.Context(), ctx)))
+		if rt.Async {
+			h.serveAsync(rt, res, req.withContext(values), ctx)
+			return
+		}
+		deadline, cancel := context.WithTimeout(values, h.responseTimeout())
+		defer cancel()
+		tracker := &responseTracker{ResponseWriter: w}
+		handlerReq := req.withContext(deadline)
+		start := time.Now()
+		err := call(h.handlerFor(rt), &Response{tracker}, handlerReq, ctx)
+		h.metrics().Handled(rt, time.Since(start), err)
+		if err != nil {
+			h.logError(handlerReq, "HTTP handler error: %s", err)
+			h.respondError(res, handlerReq, ctx, tracker.written, err)
+		}
+	}
 
 	// First check if path matches our BasePath and has valid form data
 	// If yes then attempt to decode it to match on Command, Events challenge, or CallbackID / InteractionType
 	// If no then match custom paths
+	body := req.RawBody()
 	err := r.ParseForm()
+	// Parsing the form consumed the body, replace it so that handlers can read it too
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
 	if strings.HasPrefix(r.URL.Path, h.basePath) && err == nil {
 		// Is this a url challenge from Slack?
-		body, err := ioutil.ReadAll(r.Body)
 		// This has a body, lets do stuff with it
-		if err == nil {
+		if len(body) > 0 {
 			// Decode the potential challenge interactionPayload
 			var verificationEvent slackevents.EventsAPIURLVerificationEvent
 			err := json.Unmarshal(body, &verificationEvent)
@@ -132,9 +198,9 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				// This seems to be a url verification request from Slack, check it is and respond accordingly
 				if verificationEvent.Type == slackevents.URLVerification {
 					if _, err := w.Write([]byte(verificationEvent.Challenge)); err != nil {
-						h.ErrorLogf("Failed writing challenge back to verificationEvent: %w", err)
+						log.Error(fmt.Sprintf("Failed writing challenge back to verificationEvent: %s", err), nil)
 					}
-					h.Logf("Successfully responded to URL verification requested from Slack")
+					log.Info("Successfully responded to URL verification requested from Slack", nil)
 					return
 				}
 			}
@@ -143,12 +209,12 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Is it a slash command?
 		if r.Form.Get("command") != "" {
 			sc, _ := slack.SlashCommandParse(r)
-			h.Logf("slack command triggered: %s", sc.Command)
+			log.Debug(fmt.Sprintf("slack command triggered: %s", sc.Command), requestFields(r.Context(), sc))
 			// Loop through all our routes and attempt a match on the Command
-			for _, rt := range h.Routes {
+			for _, rt := range h.routes {
 				if rt.Command == sc.Command {
 					// Send the SlackCommand struct as context
-					serve(rt.Handler, sc)
+					serve(rt, sc, nil)
 					return
 				}
 			}
@@ -159,19 +225,16 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			// Does it have a valid interaction callback payload? - If so, it's an interaction callback
 			interactionPayload, err := req.InteractionCallbackPayload()
 			if err != nil {
-				h.ErrorLogf("Error parsing interactionPayload: %s", err)
+				log.Error(fmt.Sprintf("Error parsing interactionPayload: %s", err), nil)
 				w.WriteHeader(400)
 				return
 			}
-			// Loop through all our routes and attempt a match on the InteractionType / CallbackID pair
+			// Attempt a match on the InteractionType / CallbackID pair, or ActionID for block actions
 			if interactionPayload != nil {
-				h.Logf("slack interaction callback triggered: %s", interactionPayload.CallbackID)
-				for _, rt := range h.Routes {
-					if string(interactionPayload.Type) == rt.InteractionType && interactionPayload.CallbackID == rt.CallbackID {
-						// Send the interactionPayload as context
-						serve(rt.Handler, interactionPayload)
-						return
-					}
+				log.Debug(fmt.Sprintf("slack interaction callback triggered: %s %s", interactionPayload.Type, interactionCallbackID(interactionPayload)), requestFields(r.Context(), interactionPayload))
+				if rt, ctx, params := h.matchInteraction(interactionPayload); rt != nil {
+					serve(rt, ctx, params)
+					return
 				}
 			}
 		}
@@ -179,33 +242,56 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Is it an event callback? If so see if we can route to it
 		event, err := req.EventAPIEvent(body)
 		if err == nil && event != nil {
+			if h.NoRetry {
+				w.Header().Set("X-Slack-No-Retry", "1")
+			}
+			// Slack retries events which are not acknowledged quickly, only handle the first delivery
+			if h.duplicate(req, event) {
+				return
+			}
 			eventType := event.InnerEvent.Type
-			h.Logf("slack event triggered: %s", eventType)
-			// Loop through all our routes and attempt a match on the Event type
-			for _, rt := range h.Routes {
+			log.Debug(fmt.Sprintf("slack event triggered: %s", eventType), requestFields(r.Context(), event))
+			// Loop through all our routes and attempt a match on the Event type and subtype
+			subtype := eventSubtype(body)
+			var best bestMatch
+			for _, rt := range h.routes {
 				if eventType == rt.EventType {
-					// Send the interactionPayload as context
-					h.Logf("Serving request....")
-					serve(rt.Handler, event)
-					return
+					best.consider(rt, subtype)
 				}
 			}
+			if best.route != nil {
+				// Send the interactionPayload as context
+				log.Debug("Serving request....", nil)
+				serve(best.route, event, best.params)
+				return
+			}
 			// We want to exit here because it's a valid event, but we don't have a route for it
-			h.Logf("no valid route found that matches [%s], returning", eventType)
+			log.Debug(fmt.Sprintf("no valid route found that matches [%s], returning", eventType), nil)
 			return
 		}
 
-		h.ErrorLogf("Event err:", err)
+		log.Error(fmt.Sprintf("Event err: %s", err), nil)
 	} else {
 		// If nothing else works, loop through all our routes and attempt a match on the path
-		for _, rt := range h.Routes {
+		for _, rt := range h.routes {
 			if rt.Path == r.URL.Path {
-				serve(rt.Handler, nil)
+				serve(rt, nil, nil)
 				return
 			}
 		}
 	}
 
 	// No matches - 404
-	serve(h.DefaultRoute, nil)
+	serve(&Route{Handler: h.DefaultRoute}, nil, nil)
+}
+
+// eventSubtype returns the subtype of the inner event of an Events API callback, if it has one
+func eventSubtype(body []byte) string {
+	var e struct {
+		Event struct {
+			Subtype string `json:"subtype"`
+		} `json:"event"`
+	}
+	json.Unmarshal(body, &e)
+	return e.Event.Subtype
 }
@@ -3,7 +3,7 @@ package server
 import (
 	"bytes"
 	"fmt"
-	"github.com/nlopes/slack/slackevents"
+	"github.com/slack-go/slack/slackevents"
 	"io/ioutil"
 	"net/http/httptest"
 	"testing"
@@ -16,7 +16,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/nlopes/slack"
+	"github.com/slack-go/slack"
 )
 
 var (
@@ -28,7 +28,7 @@ var (
 		logString = fmt.Sprintf("%s", i)
 	}
 	logf = func(msg string, i ...interface{}) {
-		logString = fmt.Sprintf(msg, i)
+		logString = fmt.Sprintf(msg, i...)
 	}
 	errorLog = func(i ...interface{}) {
 		logString = fmt.Sprintf(i[0].(string))
@@ -39,8 +39,13 @@ var (
 )
 
 func addSlackHeaders(body string, r *http.Request) {
+	addSlackHeadersAt(body, r, time.Now())
+}
+
+// addSlackHeadersAt signs a request as Slack would have at t
+func addSlackHeadersAt(body string, r *http.Request, t time.Time) {
 	// Set the timestamp header
-	validTime := int(time.Now().Unix())
+	validTime := int(t.Unix())
 	timestampHeader := strconv.Itoa(validTime)
 	r.Header.Set("X-Slack-Request-Timestamp", timestampHeader)
 
@@ -186,13 +191,13 @@ func TestEmojiChangedEvent(t *testing.T) {
 			t.Fatalf("Expected a *slackevents.EventsAPIEvent to be passed to the handler")
 		}
 
-		emojiEvent, ok := e.InnerEvent.Data.(*slack.EmojiChangedEvent)
+		emojiEvent, ok := e.InnerEvent.Data.(*slackevents.EmojiChangedEvent)
 		if !ok {
 			t.Fatalf("Expected to be able to cast event to a *slackevents.EmojiChangedEvent")
 		}
 
-		if emojiEvent.SubType != "remove" {
-			t.Fatalf("Unexpected value for event subtype: %s", emojiEvent.SubType)
+		if emojiEvent.Subtype != "remove" {
+			t.Fatalf("Unexpected value for event subtype: %s", emojiEvent.Subtype)
 		}
 
 		return nil
@@ -0,0 +1,58 @@
+//go:build go1.21
+// +build go1.21
+
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// SlogLogger adapts a log/slog logger to a Logger
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a Logger which writes to l, e.g. NewSlogLogger(slog.Default())
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{l: l}
+}
+
+// Debug satisfies the Logger interface
+func (l *SlogLogger) Debug(msg string, fields Fields) {
+	l.log(slog.LevelDebug, msg, fields)
+}
+
+// Info satisfies the Logger interface
+func (l *SlogLogger) Info(msg string, fields Fields) {
+	l.log(slog.LevelInfo, msg, fields)
+}
+
+// Error satisfies the Logger interface
+func (l *SlogLogger) Error(msg string, fields Fields) {
+	l.log(slog.LevelError, msg, fields)
+}
+
+// WithFields satisfies the Logger interface
+func (l *SlogLogger) WithFields(fields Fields) Logger {
+	return &SlogLogger{l: l.l.With(attrs(fields)...)}
+}
+
+func (l *SlogLogger) log(level slog.Level, msg string, fields Fields) {
+	l.l.Log(context.Background(), level, msg, attrs(fields)...)
+}
+
+// attrs converts fields in to slog key/value arguments, sorted by key
+func attrs(fields Fields) []interface{} {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := make([]interface{}, 0, len(fields)*2)
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+	return args
+}
@@ -0,0 +1,32 @@
+//go:build go1.21
+// +build go1.21
+
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var b bytes.Buffer
+	h := slog.NewTextHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	log := NewSlogLogger(slog.New(h)).WithFields(Fields{"request_id": "abc"})
+	log.Debug("debugging", nil)
+	log.Info("handled", Fields{"user_id": "U123", "command": "/help-me"})
+
+	want := "level=DEBUG msg=debugging request_id=abc\nlevel=INFO msg=handled request_id=abc command=/help-me user_id=U123\n"
+	if got := b.String(); got != want {
+		t.Fatalf("Unexpected log:\n%s", strings.TrimSpace(got))
+	}
+}
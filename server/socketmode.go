@@ -0,0 +1,323 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// socketModeOpenURL is the Slack API method which returns a Socket Mode WebSocket URL
+const socketModeOpenURL = "https://slack.com/api/apps.connections.open"
+
+// Delays between Socket Mode connection attempts, doubling after each failure
+const (
+	DefaultSocketModeMinReconnectDelay = time.Second
+	DefaultSocketModeMaxReconnectDelay = time.Minute
+)
+
+// Defaults for detecting a dead Socket Mode connection. Slack is pinged every interval and
+// the connection is dropped if nothing, including the pong, is received within the timeout
+const (
+	DefaultSocketModePingInterval = 30 * time.Second
+	DefaultSocketModeReadTimeout  = 2 * DefaultSocketModePingInterval
+)
+
+// Socket Mode envelope types
+const (
+	envelopeHello         = "hello"
+	envelopeDisconnect    = "disconnect"
+	envelopeSlashCommands = "slash_commands"
+	envelopeInteractive   = "interactive"
+	envelopeEventsAPI     = "events_api"
+)
+
+// SocketMode receives requests from Slack over a WebSocket rather than HTTP callbacks, so
+// no public endpoint or signing secret is needed. Envelopes are dispatched to the routes
+// registered on a SlackHandler exactly as if they had been received by ServeHTTP
+type SocketMode struct {
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
+	PingInterval      time.Duration
+	ReadTimeout       time.Duration
+	handler           *SlackHandler
+	appLevelToken     string
+	openURL           string
+	client            *http.Client
+	dialer            *websocket.Dialer
+}
+
+// socketModeEnvelope is a message received over a Socket Mode connection
+type socketModeEnvelope struct {
+	Type                   string          `json:"type"`
+	EnvelopeID             string          `json:"envelope_id"`
+	Payload                json.RawMessage `json:"payload"`
+	AcceptsResponsePayload bool            `json:"accepts_response_payload"`
+	Reason                 string          `json:"reason"`
+}
+
+// socketModeAck acknowledges an envelope, optionally with a response for Slack
+type socketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewSocketMode returns a SocketMode transport for h which authenticates with an
+// app-level token (xapp-...) that has the connections:write scope
+func NewSocketMode(h *SlackHandler, appLevelToken string) *SocketMode {
+	return &SocketMode{
+		MinReconnectDelay: DefaultSocketModeMinReconnectDelay,
+		MaxReconnectDelay: DefaultSocketModeMaxReconnectDelay,
+		PingInterval:      DefaultSocketModePingInterval,
+		ReadTimeout:       DefaultSocketModeReadTimeout,
+		handler:           h,
+		appLevelToken:     appLevelToken,
+		openURL:           socketModeOpenURL,
+		client:            &http.Client{Timeout: 10 * time.Second},
+		dialer:            websocket.DefaultDialer,
+	}
+}
+
+// Run connects to Slack and serves envelopes until ctx is cancelled
+// The connection is re-established whenever Slack asks for it to be refreshed or it is
+// lost. An error is only returned if Slack refuses to open a connection with the token
+func (s *SocketMode) Run(ctx context.Context) error {
+	delay := s.MinReconnectDelay
+	for {
+		err := s.connect(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var rejected *socketModeRejectedError
+		if errors.As(err, &rejected) {
+			return err
+		}
+		if err == nil {
+			// Slack asked us to reconnect, do so straight away
+			delay = s.MinReconnectDelay
+			continue
+		}
+		s.handler.logger().Error(fmt.Sprintf("Socket Mode connection lost, reconnecting in %s: %s", delay, err), nil)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > s.MaxReconnectDelay {
+			delay = s.MaxReconnectDelay
+		}
+	}
+}
+
+// socketModeRejectedError is returned when Slack will not open a connection, retrying will not help
+type socketModeRejectedError struct {
+	reason string
+}
+
+func (e *socketModeRejectedError) Error() string {
+	return fmt.Sprintf("slack refused to open a socket mode connection: %s", e.reason)
+}
+
+// open requests a WebSocket URL from Slack
+func (s *SocketMode) open(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.openURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.appLevelToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error opening connection: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OK    bool   `json:"ok"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("error decoding connection response: %d %s", resp.StatusCode, err)
+	}
+	if !body.OK {
+		return "", &socketModeRejectedError{reason: body.Error}
+	}
+	return body.URL, nil
+}
+
+// connect opens a single connection and serves envelopes until it is closed
+// A nil error means Slack asked for the connection to be refreshed
+func (s *SocketMode) connect(ctx context.Context) error {
+	wsURL, err := s.open(ctx)
+	if err != nil {
+		return err
+	}
+	conn, _, err := s.dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("error dialing %s: %s", wsURL, err)
+	}
+	defer conn.Close()
+
+	// A connection which has silently died fails the read below once the deadline passes
+	// Anything received from Slack, including pings and the pongs to our pings, extends it
+	alive := func() error {
+		return conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+	}
+	alive()
+	conn.SetPongHandler(func(string) error {
+		return alive()
+	})
+	conn.SetPingHandler(func(data string) error {
+		alive()
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(s.ReadTimeout))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+
+	// Ping Slack and unblock the read below when the caller gives up
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		ping := time.NewTicker(s.PingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-closed:
+				return
+			case <-ping.C:
+				// A failed ping is noticed when the read deadline passes
+				conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.ReadTimeout))
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+	for {
+		var env socketModeEnvelope
+		if err := conn.ReadJSON(&env); err != nil {
+			return fmt.Errorf("error reading from connection: %s", err)
+		}
+		alive()
+		switch env.Type {
+		case envelopeHello:
+			s.handler.logger().Info("Socket Mode connection established", nil)
+		case envelopeDisconnect:
+			s.handler.logger().Info(fmt.Sprintf("Slack requested Socket Mode reconnect: %s", env.Reason), nil)
+			return nil
+		default:
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				ack := s.serve(ctx, env)
+				mu.Lock()
+				defer mu.Unlock()
+				if err := conn.WriteJSON(ack); err != nil {
+					s.handler.logger().Error(fmt.Sprintf("Failed to acknowledge envelope %s: %s", env.EnvelopeID, err), Fields{"request_id": env.EnvelopeID})
+				}
+			}()
+		}
+	}
+}
+
+// serve dispatches an envelope to the registered routes and builds its acknowledgement
+// Slack is acknowledged once a synchronous handler returns, as with an HTTP response
+func (s *SocketMode) serve(ctx context.Context, env socketModeEnvelope) socketModeAck {
+	ack := socketModeAck{EnvelopeID: env.EnvelopeID}
+	r, err := env.request(withRequestID(ctx, env.EnvelopeID), s.handler.basePath)
+	if err != nil {
+		s.handler.logger().Error(fmt.Sprintf("Unable to handle envelope %s: %s", env.EnvelopeID, err), Fields{"request_id": env.EnvelopeID})
+		return ack
+	}
+	w := &envelopeResponseWriter{code: http.StatusOK}
+	s.handler.route(&Response{w}, &Request{Request: r})
+	if env.AcceptsResponsePayload {
+		ack.Payload = w.payload()
+	}
+	return ack
+}
+
+// request converts an envelope in to the request Slack would have sent over HTTP
+func (env socketModeEnvelope) request(ctx context.Context, path string) (*http.Request, error) {
+	var body []byte
+	contentType := "application/x-www-form-urlencoded"
+	switch env.Type {
+	case envelopeSlashCommands:
+		var fields map[string]interface{}
+		if err := json.Unmarshal(env.Payload, &fields); err != nil {
+			return nil, fmt.Errorf("error parsing slash command: %s", err)
+		}
+		// Slash commands are posted as form fields, anything else in the payload is not one
+		form := url.Values{}
+		for k, v := range fields {
+			if s, ok := v.(string); ok {
+				form.Set(k, s)
+			}
+		}
+		body = []byte(form.Encode())
+	case envelopeInteractive:
+		body = []byte(url.Values{"payload": {string(env.Payload)}}.Encode())
+	case envelopeEventsAPI:
+		body = env.Payload
+		contentType = "application/json"
+	default:
+		return nil, fmt.Errorf("unsupported envelope type: %s", env.Type)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", contentType)
+	return r, nil
+}
+
+// envelopeResponseWriter captures the response a handler would have sent over HTTP so it
+// can be returned to Slack in the envelope acknowledgement
+type envelopeResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func (e *envelopeResponseWriter) Header() http.Header {
+	if e.header == nil {
+		e.header = http.Header{}
+	}
+	return e.header
+}
+
+func (e *envelopeResponseWriter) Write(b []byte) (int, error) {
+	return e.body.Write(b)
+}
+
+func (e *envelopeResponseWriter) WriteHeader(code int) {
+	e.code = code
+}
+
+// payload returns the response body as an acknowledgement payload. Plain text responses
+// are sent as a message, as Slack does for HTTP responses to slash commands
+func (e *envelopeResponseWriter) payload() json.RawMessage {
+	b := bytes.TrimSpace(e.body.Bytes())
+	if e.code != http.StatusOK || len(b) == 0 {
+		return nil
+	}
+	if json.Valid(b) {
+		return b
+	}
+	j, _ := json.Marshal(map[string]string{"text": string(b)})
+	return j
+}
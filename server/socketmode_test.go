@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// socketModeStub is a local stand-in for the Slack Socket Mode API. Each accepted
+// connection is handed to the test through conns
+type socketModeStub struct {
+	*httptest.Server
+	conns chan *websocket.Conn
+}
+
+func newSocketModeStub(t *testing.T) *socketModeStub {
+	stub := &socketModeStub{conns: make(chan *websocket.Conn, 4)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xapp-TOKEN" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"url":"ws` + strings.TrimPrefix(stub.URL, "http") + `/link"}`))
+	})
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Unable to upgrade connection: %s", err)
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"type": "hello", "num_connections": 1})
+		stub.conns <- conn
+	})
+	stub.Server = httptest.NewServer(mux)
+	return stub
+}
+
+// accept waits for the client to connect
+func (s *socketModeStub) accept(t *testing.T) *websocket.Conn {
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a socket mode connection")
+		return nil
+	}
+}
+
+// send delivers an envelope and returns the acknowledgement from the client
+func send(t *testing.T, conn *websocket.Conn, envelope string) socketModeAck {
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(envelope)); err != nil {
+		t.Fatalf("Unable to send envelope: %s", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var ack socketModeAck
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("Expected an acknowledgement: %s", err)
+	}
+	return ack
+}
+
+func newSocketModeHandler(stub *socketModeStub) (*SlackHandler, *SocketMode) {
+	h := NewSlackHandler("/slack", "TOKEN", "", nil, log, logf, errorLog, errorLogf)
+	sm := NewSocketMode(h, "xapp-TOKEN")
+	sm.openURL = stub.URL + "/apps.connections.open"
+	sm.MinReconnectDelay = time.Millisecond
+	return h, sm
+}
+
+func runSocketMode(t *testing.T, sm *SocketMode) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- sm.Run(ctx)
+	}()
+	return cancel, done
+}
+
+func TestSocketModeDispatch(t *testing.T) {
+	stub := newSocketModeStub(t)
+	defer stub.Close()
+	h, sm := newSocketModeHandler(stub)
+
+	var command slack.SlashCommand
+	var requestID string
+	h.HandleCommand("/test", func(res *Response, req *Request, ctx interface{}) error {
+		command = ctx.(slack.SlashCommand)
+		requestID = RequestID(req.Context())
+		res.Text(http.StatusOK, "Hello from a slash command")
+		return nil
+	})
+	var action *BlockActionCallback
+	h.HandleBlockAction("approve", func(res *Response, req *Request, ctx interface{}) error {
+		action = ctx.(*BlockActionCallback)
+		return nil
+	})
+	var event *slackevents.EventsAPIEvent
+	h.HandleEventCallback("app_mention", func(res *Response, req *Request, ctx interface{}) error {
+		event = ctx.(*slackevents.EventsAPIEvent)
+		return nil
+	})
+
+	cancel, done := runSocketMode(t, sm)
+	conn := stub.accept(t)
+
+	ack := send(t, conn, `{"envelope_id":"1","type":"slash_commands","accepts_response_payload":true,"payload":{"command":"/test","text":"foo bar","user_id":"U123","trigger_id":"T1"}}`)
+	if ack.EnvelopeID != "1" || string(ack.Payload) != `{"text":"Hello from a slash command"}` {
+		t.Fatalf("Unexpected ack: %s %s", ack.EnvelopeID, ack.Payload)
+	}
+	if command.Text != "foo bar" || command.UserID != "U123" {
+		t.Fatalf("Unexpected slash command: %+v", command)
+	}
+	if requestID != "1" {
+		t.Fatalf("Expected the envelope ID to be used as the request ID. Got: %s", requestID)
+	}
+
+	ack = send(t, conn, `{"envelope_id":"2","type":"interactive","payload":{"type":"block_actions","actions":[{"type":"button","action_id":"approve","block_id":"request","value":"yes"}]}}`)
+	if ack.EnvelopeID != "2" || ack.Payload != nil {
+		t.Fatalf("Unexpected ack: %s %s", ack.EnvelopeID, ack.Payload)
+	}
+	if action == nil || action.Action.Value != "yes" {
+		t.Fatalf("Expected the block action to be dispatched")
+	}
+
+	ack = send(t, conn, `{"envelope_id":"3","type":"events_api","payload":{"type":"event_callback","event":{"type":"app_mention","text":"hi"}}}`)
+	if ack.EnvelopeID != "3" {
+		t.Fatalf("Unexpected ack: %s", ack.EnvelopeID)
+	}
+	if event == nil || event.InnerEvent.Type != "app_mention" {
+		t.Fatalf("Expected the event to be dispatched")
+	}
+
+	ack = send(t, conn, `{"envelope_id":"4","type":"something_new","payload":{}}`)
+	if ack.EnvelopeID != "4" {
+		t.Fatalf("Expected unsupported envelopes to be acknowledged")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestSocketModeReconnects(t *testing.T) {
+	stub := newSocketModeStub(t)
+	defer stub.Close()
+	h, sm := newSocketModeHandler(stub)
+	h.HandleCommand("/test", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+
+	cancel, done := runSocketMode(t, sm)
+	defer cancel()
+
+	// Slack refreshes connections by asking the client to disconnect
+	conn := stub.accept(t)
+	conn.WriteJSON(map[string]string{"type": "disconnect", "reason": "refresh_requested"})
+	conn = stub.accept(t)
+	// A dropped connection is retried
+	conn.Close()
+	conn = stub.accept(t)
+
+	ack := send(t, conn, `{"envelope_id":"1","type":"slash_commands","payload":{"command":"/test"}}`)
+	if ack.EnvelopeID != "1" {
+		t.Fatalf("Unexpected ack: %s", ack.EnvelopeID)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestSocketModeRejectedToken(t *testing.T) {
+	stub := newSocketModeStub(t)
+	defer stub.Close()
+	_, sm := newSocketModeHandler(stub)
+	sm.appLevelToken = "xapp-WRONG"
+
+	err := sm.Run(context.Background())
+	if err == nil || err.Error() != "slack refused to open a socket mode connection: invalid_auth" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestEnvelopeResponsePayload(t *testing.T) {
+	tt := []struct {
+		code int
+		body string
+		want string
+	}{
+		{http.StatusOK, `{"response_action":"clear"}`, `{"response_action":"clear"}`},
+		{http.StatusOK, "Hi\n", `{"text":"Hi"}`},
+		{http.StatusOK, "", ""},
+		{http.StatusNotFound, "Not found\n", ""},
+	}
+	for _, tc := range tt {
+		w := &envelopeResponseWriter{code: http.StatusOK}
+		w.WriteHeader(tc.code)
+		w.Write([]byte(tc.body))
+		got := w.payload()
+		if tc.want == "" && got != nil || tc.want != "" && string(got) != tc.want {
+			t.Fatalf("Unexpected payload for %d %q: %s", tc.code, tc.body, got)
+		}
+		if got != nil && !json.Valid(got) {
+			t.Fatalf("Payload is not JSON: %s", got)
+		}
+	}
+}
+
+func TestSocketModeDeadConnection(t *testing.T) {
+	stub := newSocketModeStub(t)
+	defer stub.Close()
+	_, sm := newSocketModeHandler(stub)
+	sm.PingInterval = 10 * time.Millisecond
+	sm.ReadTimeout = 100 * time.Millisecond
+
+	cancel, done := runSocketMode(t, sm)
+	// The stub does not read, so it never answers pings and the client gives up
+	stub.accept(t)
+	conn := stub.accept(t)
+	// Answering pings keeps the connection open
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-stub.conns:
+		t.Fatal("Expected a connection answering pings to be kept open")
+	case <-time.After(5 * sm.ReadTimeout):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestSlashCommandEnvelopeFields(t *testing.T) {
+	env := socketModeEnvelope{Type: "slash_commands", Payload: json.RawMessage(`{"command":"/test","text":"hi","is_enterprise_install":false,"extra":{"a":1}}`)}
+	r, err := env.request(context.Background(), "/slack")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	r.ParseForm()
+	if r.PostForm.Encode() != "command=%2Ftest&text=hi" {
+		t.Fatalf("Expected only string fields to be posted. Got %s", r.PostForm.Encode())
+	}
+}
@@ -0,0 +1,443 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+// Errors returned when registering a subcommand which could never be served
+var (
+	ErrDuplicateSubcommand = errors.New("subcommand is already registered")
+	ErrReservedSubcommand  = errors.New("subcommand name is reserved")
+)
+
+// ArgType controls how a positional subcommand argument is parsed
+type ArgType int
+
+// Argument types
+const (
+	StringArg  ArgType = iota // A single word, or a quoted string
+	UserArg                   // A user mention, e.g. <@U123|bob>
+	ChannelArg                // A channel mention, e.g. <#C123|general>
+	TextArg                   // The rest of the text as it was typed, must be the last argument
+)
+
+// SubcommandHandlerFunc handles a subcommand with its parsed arguments
+type SubcommandHandlerFunc func(res *Response, req *Request, sc slack.SlashCommand, args *Args) error
+
+// SubcommandOption declares the arguments and flags of a subcommand
+type SubcommandOption func(*Subcommand)
+
+// Subcommand is a verb handled under a slash command, e.g. `/help-me status <ticket>`
+type Subcommand struct {
+	Name        string
+	Description string
+	Examples    []string
+	Handler     SubcommandHandlerFunc
+	args        []argSpec
+	flags       []flagSpec
+}
+
+type argSpec struct {
+	name     string
+	typ      ArgType
+	optional bool
+}
+
+type flagSpec struct {
+	name, usage string
+	boolean     bool
+}
+
+// Arg declares a required positional argument
+func Arg(name string, t ArgType) SubcommandOption {
+	return func(s *Subcommand) {
+		s.args = append(s.args, argSpec{name: name, typ: t})
+	}
+}
+
+// OptionalArg declares a positional argument which may be omitted
+// Optional arguments must follow any required arguments
+func OptionalArg(name string, t ArgType) SubcommandOption {
+	return func(s *Subcommand) {
+		s.args = append(s.args, argSpec{name: name, typ: t, optional: true})
+	}
+}
+
+// Flag declares a flag which takes a value, given as `--name value` or `--name=value`
+func Flag(name, usage string) SubcommandOption {
+	return func(s *Subcommand) {
+		s.flags = append(s.flags, flagSpec{name: name, usage: usage})
+	}
+}
+
+// BoolFlag declares a flag which is either present or not, e.g. `--all`
+func BoolFlag(name, usage string) SubcommandOption {
+	return func(s *Subcommand) {
+		s.flags = append(s.flags, flagSpec{name: name, usage: usage, boolean: true})
+	}
+}
+
+// Example adds an example invocation of the subcommand to the generated help, e.g. "status HD-1"
+func Example(e string) SubcommandOption {
+	return func(s *Subcommand) {
+		s.Examples = append(s.Examples, e)
+	}
+}
+
+// Mention is a reference to a user or channel in the text of a slash command
+type Mention struct {
+	ID   string
+	Name string
+}
+
+// parseMention parses an escaped user or channel mention such as <@U123|bob> or <#C123>
+// The kind of mention, @ for users or # for channels, is returned with it
+func parseMention(s string) (byte, Mention, bool) {
+	if len(s) < 4 || s[0] != '<' || s[len(s)-1] != '>' || (s[1] != '@' && s[1] != '#') {
+		return 0, Mention{}, false
+	}
+	parts := strings.SplitN(s[2:len(s)-1], "|", 2)
+	m := Mention{ID: parts[0]}
+	if len(parts) == 2 {
+		m.Name = parts[1]
+	}
+	return s[1], m, true
+}
+
+// Args holds the parsed arguments and flags of a subcommand
+type Args struct {
+	values   map[string]string
+	mentions map[string]Mention
+	flags    map[string]string
+}
+
+// String returns the value of a StringArg or TextArg, or an empty string if it was not given
+func (a *Args) String(name string) string {
+	return a.values[name]
+}
+
+// User returns the ID and name of a UserArg
+func (a *Args) User(name string) Mention {
+	return a.mentions[name]
+}
+
+// Channel returns the ID and name of a ChannelArg
+func (a *Args) Channel(name string) Mention {
+	return a.mentions[name]
+}
+
+// Has reports whether an argument or flag was given
+func (a *Args) Has(name string) bool {
+	_, v := a.values[name]
+	_, m := a.mentions[name]
+	_, f := a.flags[name]
+	return v || m || f
+}
+
+// Flag returns the value of a Flag, or an empty string if it was not given
+func (a *Args) Flag(name string) string {
+	return a.flags[name]
+}
+
+// Bool reports whether a BoolFlag was given
+func (a *Args) Bool(name string) bool {
+	_, ok := a.flags[name]
+	return ok
+}
+
+// CommandRouter dispatches a slash command to a subcommand named by the first word of its text
+// Bad input is answered with an automatically generated usage message, visible only to the user
+type CommandRouter struct {
+	Command string
+	// Default is executed when the command is used without any text. The usage
+	// message is sent if it is nil
+	Default     CommandHandlerFunc
+	subcommands []*Subcommand
+	handler     *SlackHandler
+}
+
+// HandleSubcommands registers a CommandRouter for the slash command c and returns it so
+// subcommands can be added. `help` responds with the help generated by SlackHandler.Help
+func (h *SlackHandler) HandleSubcommands(c string, opts ...RouteOption) (*CommandRouter, error) {
+	cr := &CommandRouter{Command: c, handler: h}
+	opts = append(opts, func(r *Route) {
+		r.router = cr
+	})
+	if err := h.HandleCommandFunc(c, cr.serve, opts...); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Handle registers a subcommand, e.g. Handle("assign", "Assign a ticket", f, Arg("user", UserArg))
+// Names are matched ignoring case, so a name which is already registered is rejected, as is
+// help which always shows the generated help
+func (cr *CommandRouter) Handle(name, description string, f SubcommandHandlerFunc, opts ...SubcommandOption) (*Subcommand, error) {
+	if strings.EqualFold(name, "help") {
+		return nil, fmt.Errorf("%w: %s %s", ErrReservedSubcommand, cr.Command, name)
+	}
+	if cr.find(name) != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrDuplicateSubcommand, cr.Command, name)
+	}
+	s := &Subcommand{Name: name, Description: description, Handler: f}
+	for _, o := range opts {
+		o(s)
+	}
+	cr.subcommands = append(cr.subcommands, s)
+	return s, nil
+}
+
+// Subcommand returns the registered subcommand with the given name, or nil
+func (cr *CommandRouter) Subcommand(name string) *Subcommand {
+	return cr.find(name)
+}
+
+// Subcommands returns the registered subcommands
+func (cr *CommandRouter) Subcommands() []*Subcommand {
+	return cr.subcommands
+}
+
+// Usage describes every subcommand of the router
+func (cr *CommandRouter) Usage() string {
+	lines := []string{"Usage:"}
+	for _, s := range cr.subcommands {
+		line := fmt.Sprintf("`%s`", s.usage(cr.Command))
+		if s.Description != "" {
+			line += " - " + s.Description
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// usage describes how to invoke the subcommand
+func (s *Subcommand) usage(command string) string {
+	parts := []string{command, s.Name}
+	for _, a := range s.args {
+		name := a.name
+		switch a.typ {
+		case UserArg:
+			name = "@" + name
+		case ChannelArg:
+			name = "#" + name
+		case TextArg:
+			name += "..."
+		}
+		if a.optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	for _, f := range s.flags {
+		if f.boolean {
+			parts = append(parts, fmt.Sprintf("[--%s]", f.name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[--%s <%s>]", f.name, f.name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// flagUsage describes the flags of the subcommand
+func (s *Subcommand) flagUsage() string {
+	var lines []string
+	for _, f := range s.flags {
+		lines = append(lines, fmt.Sprintf("`--%s` %s", f.name, f.usage))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (cr *CommandRouter) find(name string) *Subcommand {
+	for _, s := range cr.subcommands {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (cr *CommandRouter) serve(res *Response, req *Request, sc slack.SlashCommand) error {
+	start := skipSpace(sc.Text, 0)
+	if start == len(sc.Text) && cr.Default != nil {
+		return cr.Default(res, req, sc)
+	}
+	if start == len(sc.Text) {
+		return cr.reply(res, req, cr.Usage())
+	}
+	name, end, err := scanWord(sc.Text, start)
+	if err != nil {
+		return cr.reply(res, req, fmt.Sprintf("%s\n\n%s", err, cr.Usage()))
+	}
+	if strings.EqualFold(name, "help") && cr.handler != nil {
+		return cr.handler.HelpHandler()(res, req, sc)
+	}
+	if strings.EqualFold(name, "help") {
+		return cr.reply(res, req, cr.Usage())
+	}
+	s := cr.find(name)
+	if s == nil {
+		return cr.reply(res, req, fmt.Sprintf("Unknown command `%s %s`\n\n%s", cr.Command, name, cr.Usage()))
+	}
+	args, err := s.parse(sc.Text[end:])
+	if err != nil {
+		msg := fmt.Sprintf("%s\n\nUsage: `%s`", err, s.usage(cr.Command))
+		if flags := s.flagUsage(); flags != "" {
+			msg += "\n" + flags
+		}
+		return cr.reply(res, req, msg)
+	}
+	return s.Handler(res, req, sc, args)
+}
+
+// reply sends a message only the user who ran the command can see
+func (cr *CommandRouter) reply(res *Response, req *Request, text string) error {
+	return ReplyEphemeral(res, req, &slack.Msg{Text: text})
+}
+
+// ReplyEphemeral responds to a slash command with a message only the user can see
+// Async routes have already responded to Slack so the response_url is used instead
+func ReplyEphemeral(res *Response, req *Request, msg *slack.Msg) error {
+	msg.ResponseType = "ephemeral"
+	if rt := RouteFromContext(req.Context()); rt != nil && rt.Async {
+		return req.PostResponse(msg)
+	}
+	return res.JSON(200, msg)
+}
+
+// Parse parses the text following the subcommand name in to its arguments and flags
+func (s *Subcommand) Parse(text string) (*Args, error) {
+	return s.parse(text)
+}
+
+// parse matches the words of text against the declared arguments and flags. A TextArg takes
+// the rest of the text exactly as it was typed, so nothing after it is parsed as a flag
+func (s *Subcommand) parse(text string) (*Args, error) {
+	args := &Args{values: map[string]string{}, mentions: map[string]Mention{}, flags: map[string]string{}}
+	var unexpected []string
+	n := 0 // The number of positional arguments given
+	for pos := skipSpace(text, 0); pos < len(text); pos = skipSpace(text, pos) {
+		if n < len(s.args) && s.args[n].typ == TextArg {
+			args.values[s.args[n].name] = strings.TrimRightFunc(text[pos:], unicode.IsSpace)
+			n++
+			break
+		}
+		w, end, err := scanWord(text, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos = end
+		if !strings.HasPrefix(w, "--") || len(w) == 2 {
+			if n >= len(s.args) {
+				unexpected = append(unexpected, w)
+				continue
+			}
+			if err := args.set(s.args[n], w); err != nil {
+				return nil, err
+			}
+			n++
+			continue
+		}
+		name, value := w[2:], ""
+		hasValue := false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		f := s.flag(name)
+		if f == nil {
+			return nil, fmt.Errorf("Unknown flag `--%s`", name)
+		}
+		if f.boolean {
+			if hasValue {
+				return nil, fmt.Errorf("Flag `--%s` does not take a value", name)
+			}
+		} else if !hasValue {
+			if pos = skipSpace(text, pos); pos == len(text) {
+				return nil, fmt.Errorf("Flag `--%s` needs a value", name)
+			}
+			if value, pos, err = scanWord(text, pos); err != nil {
+				return nil, err
+			}
+		}
+		args.flags[name] = value
+	}
+
+	for _, a := range s.args[n:] {
+		if !a.optional {
+			return nil, fmt.Errorf("Missing %s", a.name)
+		}
+	}
+	if len(unexpected) > 0 {
+		return nil, fmt.Errorf("Unexpected %s", strings.Join(unexpected, " "))
+	}
+	return args, nil
+}
+
+// set parses a positional argument of the type a was declared with
+func (args *Args) set(a argSpec, w string) error {
+	switch a.typ {
+	case UserArg, ChannelArg:
+		kind, m, ok := parseMention(w)
+		if a.typ == UserArg && (!ok || kind != '@') {
+			return fmt.Errorf("Expected %s to be a user mention, got `%s`", a.name, w)
+		}
+		if a.typ == ChannelArg && (!ok || kind != '#') {
+			return fmt.Errorf("Expected %s to be a channel mention, got `%s`", a.name, w)
+		}
+		args.mentions[a.name] = m
+	default:
+		args.values[a.name] = w
+	}
+	return nil
+}
+
+func (s *Subcommand) flag(name string) *flagSpec {
+	for i := range s.flags {
+		if s.flags[i].name == name {
+			return &s.flags[i]
+		}
+	}
+	return nil
+}
+
+// skipSpace returns the position of the first character at or after pos which is not whitespace
+func skipSpace(text string, pos int) int {
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// scanWord reads the word starting at pos, up to the next whitespace outside of quotes, and
+// returns it without its quotes along with the position following it
+// Slack clients may send curly quotes so those are treated as quotes too
+func scanWord(text string, pos int) (string, int, error) {
+	var current strings.Builder
+	quoted := false
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if unicode.IsSpace(r) && !quoted {
+			break
+		}
+		pos += size
+		if r == '"' || r == '“' || r == '”' {
+			quoted = !quoted
+		} else {
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return "", pos, fmt.Errorf("Unterminated quote")
+	}
+	return current.String(), pos, nil
+}
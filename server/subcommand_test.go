@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func commandRaw(command, text string) string {
+	return url.Values{
+		"command":    {command},
+		"text":       {text},
+		"user_id":    {"UABC123"},
+		"channel_id": {"C1AB2C3DE"},
+	}.Encode()
+}
+
+func newTestRouter(called *[]string, args **Args) *SlackHandler {
+	record := func(name string) SubcommandHandlerFunc {
+		return func(res *Response, req *Request, sc slack.SlashCommand, a *Args) error {
+			*called = append(*called, name)
+			*args = a
+			return nil
+		}
+	}
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	cr, _ := s.HandleSubcommands("/help-me")
+	cr.Handle("status", "Show a ticket", record("status"), Arg("ticket", StringArg))
+	cr.Handle("assign", "Assign a ticket", record("assign"), Arg("assignee", UserArg), Arg("ticket", StringArg), Flag("note", "Added to the ticket thread"))
+	cr.Handle("list", "List tickets", record("list"), OptionalArg("filter", StringArg), BoolFlag("all", "Include resolved tickets"))
+	cr.Handle("announce", "Post to a channel", record("announce"), Arg("channel", ChannelArg), Arg("message", TextArg))
+	return s
+}
+
+func TestSubcommandParsing(t *testing.T) {
+	tt := []struct {
+		text  string
+		check func(a *Args) bool
+	}{
+		{"status 123", func(a *Args) bool { return a.String("ticket") == "123" }},
+		{"assign <@U123|bob> 123", func(a *Args) bool {
+			return a.User("assignee") == Mention{ID: "U123", Name: "bob"} && a.String("ticket") == "123" && !a.Has("note")
+		}},
+		{"assign <@U123> 123 --note \"on leave tomorrow\"", func(a *Args) bool {
+			return a.User("assignee").ID == "U123" && a.Flag("note") == "on leave tomorrow"
+		}},
+		{"ASSIGN --note=urgent <@U123> 123", func(a *Args) bool { return a.Flag("note") == "urgent" && a.String("ticket") == "123" }},
+		{"list", func(a *Args) bool { return !a.Has("filter") && !a.Bool("all") }},
+		{"list mine --all", func(a *Args) bool { return a.String("filter") == "mine" && a.Bool("all") }},
+		// The rest of the text is kept as it was typed
+		{"announce <#C123|general> VPN is   back “up”", func(a *Args) bool {
+			return a.Channel("channel").ID == "C123" && a.String("message") == "VPN is   back “up”"
+		}},
+		{"announce <#C123> VPN -- down --since monday", func(a *Args) bool { return a.String("message") == "VPN -- down --since monday" }},
+		{"announce <#C123> Don\"t panic ", func(a *Args) bool { return a.String("message") == "Don\"t panic" }},
+	}
+	for _, tc := range tt {
+		t.Run(tc.text, func(t *testing.T) {
+			var called []string
+			var args *Args
+			s := newTestRouter(&called, &args)
+			resp := performGenericFormRequest(commandRaw("/help-me", tc.text), basePath, s)
+			if resp.StatusCode != 200 || len(called) != 1 {
+				t.Fatalf("Expected a subcommand to be called. Got %d %v", resp.StatusCode, called)
+			}
+			if !tc.check(args) {
+				t.Fatalf("Unexpected args: %+v", args)
+			}
+		})
+	}
+}
+
+func TestSubcommandUsage(t *testing.T) {
+	tt := []struct {
+		text, want string
+	}{
+		{"", "Usage:\n`/help-me status <ticket>` - Show a ticket\n`/help-me assign <@assignee> <ticket> [--note <note>]` - Assign a ticket"},
+		{"frobnicate", "Unknown command `/help-me frobnicate`\n\nUsage:"},
+		{"status", "Missing ticket\n\nUsage: `/help-me status <ticket>`"},
+		{"status 1 2", "Unexpected 2\n\n"},
+		{"assign bob 123", "Expected assignee to be a user mention, got `bob`"},
+		{"assign <#C123> 123", "Expected assignee to be a user mention, got `<#C123>`"},
+		{"assign <@U123> 123 --note", "Flag `--note` needs a value\n\nUsage: `/help-me assign <@assignee> <ticket> [--note <note>]`\n`--note` Added to the ticket thread"},
+		{"list --mine", "Unknown flag `--mine`"},
+		{"list --all=yes", "Flag `--all` does not take a value"},
+		{"status \"123", "Unterminated quote"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.text, func(t *testing.T) {
+			var called []string
+			var args *Args
+			s := newTestRouter(&called, &args)
+			resp := performGenericFormRequest(commandRaw("/help-me", tc.text), basePath, s)
+			if len(called) != 0 {
+				t.Fatalf("Subcommand should not have been called: %v", called)
+			}
+			var msg slack.Msg
+			if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+				t.Fatalf("Expected a JSON response: %s", err)
+			}
+			if msg.ResponseType != "ephemeral" {
+				t.Fatalf("Usage should only be visible to the user")
+			}
+			if !strings.HasPrefix(msg.Text, tc.want) {
+				t.Fatalf("Unexpected usage message:\n%s", msg.Text)
+			}
+		})
+	}
+}
+
+func TestSubcommandDefault(t *testing.T) {
+	called := false
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	cr, _ := s.HandleSubcommands("/help-me")
+	cr.Default = func(res *Response, req *Request, sc slack.SlashCommand) error {
+		called = true
+		return nil
+	}
+	performGenericFormRequest(commandRaw("/help-me", "  "), basePath, s)
+	if !called {
+		t.Fatalf("Expected the default handler to be called without any text")
+	}
+}
+
+func TestDuplicateSubcommand(t *testing.T) {
+	cr := &CommandRouter{Command: "/help-me"}
+	if _, err := cr.Handle("status", "Show a ticket", nil); err != nil {
+		t.Fatalf("Unexpected error registering a subcommand: %s", err)
+	}
+	_, err := cr.Handle("Status", "Show a ticket again", nil)
+	if !errors.Is(err, ErrDuplicateSubcommand) || err.Error() != "subcommand is already registered: /help-me Status" {
+		t.Fatalf("Expected a duplicate subcommand to be rejected. Got %v", err)
+	}
+	_, err = cr.Handle("HELP", "Help that would never be shown", nil)
+	if !errors.Is(err, ErrReservedSubcommand) || err.Error() != "subcommand name is reserved: /help-me HELP" {
+		t.Fatalf("Expected help to be rejected. Got %v", err)
+	}
+	if len(cr.Subcommands()) != 1 {
+		t.Fatalf("The rejected subcommands should not be registered")
+	}
+}
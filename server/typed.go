@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// CommandHandlerFunc handles a slash command
+type CommandHandlerFunc func(res *Response, req *Request, sc slack.SlashCommand) error
+
+// InteractionHandlerFunc handles an interaction such as a view submission or shortcut
+type InteractionHandlerFunc func(res *Response, req *Request, ic *slack.InteractionCallback) error
+
+// BlockActionHandlerFunc handles a single action from a block_actions interaction
+type BlockActionHandlerFunc func(res *Response, req *Request, b *BlockActionCallback) error
+
+// EventHandlerFunc handles an Events API callback
+type EventHandlerFunc func(res *Response, req *Request, e *slackevents.EventsAPIEvent) error
+
+// PayloadTypeError is returned when a typed handler is passed a payload of the wrong type
+// The dispatcher always passes the documented type, so this means a middleware replaced it
+type PayloadTypeError struct {
+	Want string
+	Got  interface{}
+}
+
+func (e *PayloadTypeError) Error() string {
+	return fmt.Sprintf("expected a %s payload, got %T", e.Want, e.Got)
+}
+
+// HandleCommandFunc registers a typed handler for a slash command
+func (h *SlackHandler) HandleCommandFunc(c string, f CommandHandlerFunc, opts ...RouteOption) error {
+	return h.HandleCommand(c, f.handler(), opts...)
+}
+
+// HandleInteractionCallbackFunc registers a typed handler for an InteractionType / CallbackID pair
+func (h *SlackHandler) HandleInteractionCallbackFunc(it, cid string, f InteractionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleInteractionCallback(it, cid, f.handler(), opts...)
+}
+
+// HandleBlockActionFunc registers a typed handler for a block action
+func (h *SlackHandler) HandleBlockActionFunc(actionID string, f BlockActionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleBlockAction(actionID, f.handler(), opts...)
+}
+
+// HandleViewSubmissionFunc registers a typed handler for a modal submission
+func (h *SlackHandler) HandleViewSubmissionFunc(cid string, f InteractionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleViewSubmission(cid, f.handler(), opts...)
+}
+
+// HandleViewClosedFunc registers a typed handler for a modal being closed
+func (h *SlackHandler) HandleViewClosedFunc(cid string, f InteractionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleViewClosed(cid, f.handler(), opts...)
+}
+
+// HandleShortcutFunc registers a typed handler for a global shortcut
+func (h *SlackHandler) HandleShortcutFunc(cid string, f InteractionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleShortcut(cid, f.handler(), opts...)
+}
+
+// HandleMessageShortcutFunc registers a typed handler for a message shortcut
+func (h *SlackHandler) HandleMessageShortcutFunc(cid string, f InteractionHandlerFunc, opts ...RouteOption) error {
+	return h.HandleMessageShortcut(cid, f.handler(), opts...)
+}
+
+// HandleEventCallbackFunc registers a typed handler for an Events API event type
+func (h *SlackHandler) HandleEventCallbackFunc(et string, f EventHandlerFunc, opts ...RouteOption) error {
+	return h.HandleEventCallback(et, f.handler(), opts...)
+}
+
+func (f CommandHandlerFunc) handler() SlackHandlerFunc {
+	if f == nil {
+		return nil
+	}
+	return func(res *Response, req *Request, ctx interface{}) error {
+		sc, ok := ctx.(slack.SlashCommand)
+		if !ok {
+			return &PayloadTypeError{Want: "slack.SlashCommand", Got: ctx}
+		}
+		return f(res, req, sc)
+	}
+}
+
+func (f InteractionHandlerFunc) handler() SlackHandlerFunc {
+	if f == nil {
+		return nil
+	}
+	return func(res *Response, req *Request, ctx interface{}) error {
+		ic, ok := ctx.(*slack.InteractionCallback)
+		if !ok || ic == nil {
+			return &PayloadTypeError{Want: "*slack.InteractionCallback", Got: ctx}
+		}
+		return f(res, req, ic)
+	}
+}
+
+func (f BlockActionHandlerFunc) handler() SlackHandlerFunc {
+	if f == nil {
+		return nil
+	}
+	return func(res *Response, req *Request, ctx interface{}) error {
+		b, ok := ctx.(*BlockActionCallback)
+		if !ok || b == nil {
+			return &PayloadTypeError{Want: "*server.BlockActionCallback", Got: ctx}
+		}
+		return f(res, req, b)
+	}
+}
+
+func (f EventHandlerFunc) handler() SlackHandlerFunc {
+	if f == nil {
+		return nil
+	}
+	return func(res *Response, req *Request, ctx interface{}) error {
+		e, ok := ctx.(*slackevents.EventsAPIEvent)
+		if !ok || e == nil {
+			return &PayloadTypeError{Want: "*slackevents.EventsAPIEvent", Got: ctx}
+		}
+		return f(res, req, e)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestTypedHandlers(t *testing.T) {
+	var called []string
+	s := NewSlackHandler(basePath, "TOKEN", slackSecret, &dnHeader, log, logf, errorLog, errorLogf)
+	s.HandleCommandFunc("/bob-test", func(res *Response, req *Request, sc slack.SlashCommand) error {
+		called = append(called, "command:"+sc.UserName)
+		return nil
+	})
+	s.HandleBlockActionFunc("claim", func(res *Response, req *Request, b *BlockActionCallback) error {
+		called = append(called, "block:"+b.Action.Value)
+		return nil
+	})
+	s.HandleViewSubmissionFunc("HelpRequest", func(res *Response, req *Request, ic *slack.InteractionCallback) error {
+		called = append(called, "view:"+ic.View.PrivateMetadata)
+		return nil
+	})
+	s.HandleEventCallbackFunc("emoji_changed", func(res *Response, req *Request, e *slackevents.EventsAPIEvent) error {
+		called = append(called, "event:"+e.InnerEvent.Type)
+		return nil
+	})
+
+	performGenericFormRequest(slashCommandRaw, basePath, s)
+	performGenericFormRequest(interactionRaw(`{"type":"block_actions","user":{"id":"W12A3BCDEF"},"actions":[{"type":"button","action_id":"claim","block_id":"ticket","value":"1234"}]}`), basePath, s)
+	performGenericFormRequest(interactionRaw(`{"type":"view_submission","user":{"id":"W12A3BCDEF"},"view":{"type":"modal","callback_id":"HelpRequest","private_metadata":"C1AB2C3DE"}}`), basePath, s)
+	performGenericJsonRequest(`{"event":{"type":"emoji_changed","subtype":"remove","names":["test_emoji"]},"type":"event_callback"}`, basePath, s)
+
+	want := "command:bob.smith,block:1234,view:C1AB2C3DE,event:emoji_changed"
+	if got := strings.Join(called, ","); got != want {
+		t.Logf("ErrString: %s", logString)
+		t.Fatalf("Unexpected handlers called: %s", got)
+	}
+}
+
+func TestTypedHandlerPayloadMismatch(t *testing.T) {
+	h := CommandHandlerFunc(func(res *Response, req *Request, sc slack.SlashCommand) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	})
+	err := h.handler()(nil, nil, "foobar")
+	if _, ok := err.(*PayloadTypeError); !ok || err.Error() != "expected a slack.SlashCommand payload, got string" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var nilAction *BlockActionCallback
+	b := BlockActionHandlerFunc(func(res *Response, req *Request, b *BlockActionCallback) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	})
+	if _, ok := b.handler()(nil, nil, nilAction).(*PayloadTypeError); !ok {
+		t.Fatalf("Expected a nil payload to be rejected")
+	}
+	i := InteractionHandlerFunc(func(res *Response, req *Request, ic *slack.InteractionCallback) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	})
+	if _, ok := i.handler()(nil, nil, 42).(*PayloadTypeError); !ok {
+		t.Fatalf("Expected an int payload to be rejected")
+	}
+	e := EventHandlerFunc(func(res *Response, req *Request, e *slackevents.EventsAPIEvent) error {
+		t.Fatalf("Handler should not have been executed")
+		return nil
+	})
+	if _, ok := e.handler()(nil, nil, nil).(*PayloadTypeError); !ok {
+		t.Fatalf("Expected a nil payload to be rejected")
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Defaults for the age of requests which are accepted. Slack recommends rejecting requests
+// more than five minutes old
+const (
+	DefaultValidationWindow = 5 * time.Minute
+	DefaultClockSkew        = time.Minute
+)
+
+// DefaultReplaySize is the number of signatures the ReplayStore created by New can remember
+// Requests are rejected while it is full of signatures which are still within the window
+const DefaultReplaySize = 100000
+
+// Errors returned when a request fails validation. They are wrapped with the details of the
+// failure so use errors.Is to tell them apart
+var (
+	ErrInvalidDN        = errors.New("invalid CN in DN header")
+	ErrInvalidTimestamp = errors.New("invalid timestamp sent from slack")
+	ErrStaleTimestamp   = errors.New("stale timestamp sent from slack")
+	ErrSkewedTimestamp  = errors.New("future timestamp sent from slack")
+	ErrInvalidBody      = errors.New("invalid request body sent from slack")
+	ErrInvalidSignature = errors.New("invalid signature sent from slack")
+	ErrReplayedRequest  = errors.New("replayed request sent from slack")
+)
+
+// validationWindow returns how old a request may be, which is never 0
+func (h *SlackHandler) validationWindow() time.Duration {
+	if h.ValidationWindow > 0 {
+		return h.ValidationWindow
+	}
+	return DefaultValidationWindow
+}
+
+// clockSkew returns how far ahead of our clock a request's timestamp may be
+func (h *SlackHandler) clockSkew() time.Duration {
+	if h.ClockSkew > 0 {
+		return h.ClockSkew
+	}
+	return DefaultClockSkew
+}
+
+// validateRequest checks the client which sent the request and the handler's signing secrets,
+// logging which secret it was signed with so that an old one can be retired once it is no longer used
+// A signature is only accepted once, so a captured request can not be replayed
+// The identity of the client is returned when mutual TLS is in use
+func (h *SlackHandler) validateRequest(req *Request) (*ClientIdentity, error) {
+	id, err := h.verifyClient(req)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	if h.secrets != nil {
+		s, err := h.secrets.Secrets()
+		if err != nil {
+			return nil, err
+		}
+		secrets = s
+	}
+	i, err := req.verify(secrets, nil, h.validationWindow(), h.clockSkew(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	fields := Fields{"path": req.URL.Path, "signing_secret": i + 1}
+	if h.ReplayStore != nil {
+		seen, err := h.replayed(req.Header.Get("X-Slack-Signature"))
+		if errors.Is(err, ErrSeenStoreFull) {
+			return nil, fmt.Errorf("unable to check for a replayed request: %w", err)
+		}
+		if err != nil {
+			h.logger().Error(fmt.Sprintf("Failed to check for a replayed request: %s", err), fields)
+		} else if seen {
+			return nil, fmt.Errorf("%w: signature has already been used", ErrReplayedRequest)
+		}
+	}
+	msg := fmt.Sprintf("Request signed with signing secret %d of %d", i+1, len(secrets))
+	if i > 0 {
+		// Slack is still using an older secret
+		h.logger().Info(msg, fields)
+	} else {
+		h.logger().Debug(msg, fields)
+	}
+	return id, nil
+}
+
+// replayed records a signature in the ReplayStore and reports whether it had already been used
+// Signatures must be remembered for as long as their timestamps are accepted
+func (h *SlackHandler) replayed(sig string) (bool, error) {
+	if s, ok := h.ReplayStore.(ExpiringSeenStore); ok {
+		return s.SeenFor(sig, h.validationWindow()+h.clockSkew())
+	}
+	return h.ReplayStore.Seen(sig)
+}
+
+// rejectionReason returns the reason passed to Metrics.Rejected for a validation error
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, ErrStaleTimestamp):
+		return RejectedStale
+	case errors.Is(err, ErrSkewedTimestamp):
+		return RejectedSkewed
+	case errors.Is(err, ErrReplayedRequest):
+		return RejectedReplayed
+	case errors.Is(err, ErrInvalidSignature):
+		return RejectedSignature
+	default:
+		return RejectedInvalid
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestTimestamps(t *testing.T) {
+	tt := []struct {
+		name   string
+		age    time.Duration
+		err    error
+		reason string
+	}{
+		{"Recent", 4 * time.Minute, nil, ""},
+		{"Stale", 6 * time.Minute, ErrStaleTimestamp, RejectedStale},
+		{"Within skew", -30 * time.Second, nil, ""},
+		{"Future", -2 * time.Minute, ErrSkewedTimestamp, RejectedSkewed},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", basePath, bytes.NewBufferString("text"))
+			addSlackHeadersAt("text", req, time.Now().Add(-tc.age))
+			r := &Request{Request: req}
+			_, err := r.ValidateSecrets([]string{slackSecret}, nil)
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("Expected %v. Got %v", tc.err, err)
+			}
+			if err != nil && rejectionReason(err) != tc.reason {
+				t.Fatalf("Unexpected rejection reason: %s", rejectionReason(err))
+			}
+		})
+	}
+}
+
+// ttlRecorder is an ExpiringSeenStore which records the ttl it was last passed
+type ttlRecorder struct {
+	ttl time.Duration
+}
+
+func (r *ttlRecorder) Seen(id string) (bool, error) {
+	return false, nil
+}
+
+func (r *ttlRecorder) SeenFor(id string, ttl time.Duration) (bool, error) {
+	r.ttl = ttl
+	return false, nil
+}
+
+func TestConfigurableValidationWindow(t *testing.T) {
+	replays := &ttlRecorder{}
+	s := New(WithSigningSecret(slackSecret), WithReplayStore(replays))
+	// The window may be changed after the handler is created
+	s.ValidationWindow, s.ClockSkew = time.Minute, 5*time.Minute
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	send := func(at time.Time) int {
+		req := httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString(slashCommandRaw))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addSlackHeadersAt(slashCommandRaw, req, at)
+		return performGenericRequest(req, s).StatusCode
+	}
+	if code := send(time.Now().Add(-2 * time.Minute)); code != 400 {
+		t.Fatalf("Expected a request older than the window to be rejected. Got %d", code)
+	}
+	if code := send(time.Now().Add(4 * time.Minute)); code != 200 {
+		t.Fatalf("Expected a request within the skew to be accepted. Got %d", code)
+	}
+	if replays.ttl != 6*time.Minute {
+		t.Fatalf("Expected signatures to be remembered for the window and skew. Got %s", replays.ttl)
+	}
+}
+
+func TestReplayedRequest(t *testing.T) {
+	m := &recordingMetrics{}
+	s := New(WithSigningSecret(slackSecret), WithMetrics(m), WithLogger(FuncLogger{ErrorLogf: errorLogf}))
+	calls := 0
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		calls++
+		return nil
+	})
+	req := httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString(slashCommandRaw))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	addSlackHeaders(slashCommandRaw, req)
+	replay := req.Clone(req.Context())
+	replay.Body = httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString(slashCommandRaw)).Body
+
+	if resp := performGenericRequest(req, s); resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got %d", resp.StatusCode)
+	}
+	if resp := performGenericRequest(replay, s); resp.StatusCode != 400 {
+		t.Fatalf("Expected the replay to be rejected. Got %d", resp.StatusCode)
+	}
+	if calls != 1 || strings.Join(m.got, ",") != "handled command /bob-test <nil>,rejected replayed" {
+		t.Fatalf("Unexpected calls %d and metrics %v", calls, m.got)
+	}
+	if !strings.HasPrefix(logString, "Bad request from slack: replayed request sent from slack") {
+		t.Fatalf("Unexpected error string: %s", logString)
+	}
+}
+
+func TestBadSignatureIsNotRemembered(t *testing.T) {
+	s := New(WithSigningSecret(slackSecret))
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	req := httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString("forged"))
+	addSlackHeaders(slashCommandRaw, req)
+	performGenericRequest(req, s)
+	// The genuine request with the same signature must still be accepted
+	if resp := performGenericFormRequest(slashCommandRaw, DefaultBasePath, s); resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status. Got %d", resp.StatusCode)
+	}
+}
+
+func TestReplayStoreFull(t *testing.T) {
+	s := New(WithSigningSecret(slackSecret), WithReplayStore(NewMemoryReplayStore(1)), WithLogger(FuncLogger{ErrorLogf: errorLogf}))
+	s.HandleCommand("/bob-test", func(res *Response, req *Request, ctx interface{}) error {
+		return nil
+	})
+	send := func(at time.Time) int {
+		req := httptest.NewRequest("POST", DefaultBasePath, bytes.NewBufferString(slashCommandRaw))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addSlackHeadersAt(slashCommandRaw, req, at)
+		return performGenericRequest(req, s).StatusCode
+	}
+	if code := send(time.Now()); code != 200 {
+		t.Fatalf("Expected a 200 status. Got %d", code)
+	}
+	// The first signature can not be forgotten while it is within the window
+	if code := send(time.Now().Add(-time.Second)); code != 400 {
+		t.Fatalf("Expected the request to be rejected while the store is full. Got %d", code)
+	}
+	if !strings.HasPrefix(logString, "Bad request from slack: unable to check for a replayed request: seen store is full") {
+		t.Fatalf("Unexpected error string: %s", logString)
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var ticketsBucket = []byte("tickets")
+
+// Bolt is a TicketStore which persists tickets to disk using BoltDB
+type Bolt struct {
+	db     *bolt.DB
+	prefix string
+}
+
+// NewBolt opens, creating if necessary, the database at path and returns a Bolt store
+// which prefixes ticket IDs with prefix
+func NewBolt(path, prefix string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening ticket database: %s", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(ticketsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error initialising ticket database: %s", err)
+	}
+	return &Bolt{db: db, prefix: prefix}, nil
+}
+
+// Close releases the database
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// Create assigns a new ID to t and stores it
+func (b *Bolt) Create(t *Ticket) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(ticketsBucket)
+		seq, err := bkt.NextSequence()
+		if err != nil {
+			return err
+		}
+		t.ID = fmt.Sprintf("%s-%d", b.prefix, seq)
+		t.CreatedAt = time.Now()
+		t.UpdatedAt = t.CreatedAt
+		return put(bkt, t)
+	})
+}
+
+// Get returns the ticket with the given ID or ErrNotFound
+func (b *Bolt) Get(id string) (*Ticket, error) {
+	var t *Ticket
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(ticketsBucket).Get([]byte(normaliseID(b.prefix, id)))
+		if v == nil {
+			return ErrNotFound
+		}
+		t = &Ticket{}
+		return json.Unmarshal(v, t)
+	})
+	return t, err
+}
+
+// Update replaces a stored ticket or returns ErrNotFound
+func (b *Bolt) Update(t *Ticket) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(ticketsBucket)
+		if bkt.Get([]byte(t.ID)) == nil {
+			return ErrNotFound
+		}
+		t.UpdatedAt = time.Now()
+		return put(bkt, t)
+	})
+}
+
+// Modify atomically applies f to the ticket with the given ID and stores the result
+// The ticket is read and written in a single transaction
+func (b *Bolt) Modify(id string, f func(*Ticket) error) (*Ticket, error) {
+	var t *Ticket
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(ticketsBucket)
+		v := bkt.Get([]byte(normaliseID(b.prefix, id)))
+		if v == nil {
+			return ErrNotFound
+		}
+		t = &Ticket{}
+		if err := json.Unmarshal(v, t); err != nil {
+			return err
+		}
+		if err := f(t); err != nil {
+			return err
+		}
+		t.UpdatedAt = time.Now()
+		return put(bkt, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// List returns the tickets matching f in the order they were created
+func (b *Bolt) List(f Filter) ([]*Ticket, error) {
+	var tickets []*Ticket
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(ticketsBucket).ForEach(func(k, v []byte) error {
+			t := &Ticket{}
+			if err := json.Unmarshal(v, t); err != nil {
+				return fmt.Errorf("error decoding ticket %s: %s", k, err)
+			}
+			if f.Matches(t) {
+				tickets = append(tickets, t)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortTickets(tickets)
+	return tickets, nil
+}
+
+func put(bkt *bolt.Bucket, t *Ticket) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return bkt.Put([]byte(t.ID), v)
+}
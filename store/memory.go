@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Memory is a TicketStore which holds tickets in memory. It is intended for tests
+// and short lived deployments, tickets are lost when the process exits
+type Memory struct {
+	mu      sync.RWMutex
+	prefix  string
+	seq     uint64
+	tickets map[string]*Ticket
+}
+
+// NewMemory returns an empty Memory store which prefixes ticket IDs with prefix
+func NewMemory(prefix string) *Memory {
+	return &Memory{prefix: prefix, tickets: map[string]*Ticket{}}
+}
+
+// Create assigns a new ID to t and stores it
+func (m *Memory) Create(t *Ticket) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	t.ID = fmt.Sprintf("%s-%d", m.prefix, m.seq)
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	m.tickets[t.ID] = copyTicket(t)
+	return nil
+}
+
+// Get returns the ticket with the given ID or ErrNotFound
+func (m *Memory) Get(id string) (*Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.tickets[normaliseID(m.prefix, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyTicket(t), nil
+}
+
+// Update replaces a stored ticket or returns ErrNotFound
+func (m *Memory) Update(t *Ticket) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tickets[t.ID]; !ok {
+		return ErrNotFound
+	}
+	t.UpdatedAt = time.Now()
+	m.tickets[t.ID] = copyTicket(t)
+	return nil
+}
+
+// Modify atomically applies f to the ticket with the given ID and stores the result
+func (m *Memory) Modify(id string, f func(*Ticket) error) (*Ticket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.tickets[normaliseID(m.prefix, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	t := copyTicket(stored)
+	if err := f(t); err != nil {
+		return nil, err
+	}
+	t.UpdatedAt = time.Now()
+	m.tickets[stored.ID] = copyTicket(t)
+	return t, nil
+}
+
+// List returns the tickets matching f in the order they were created
+func (m *Memory) List(f Filter) ([]*Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var tickets []*Ticket
+	for _, t := range m.tickets {
+		if f.Matches(t) {
+			tickets = append(tickets, copyTicket(t))
+		}
+	}
+	sortTickets(tickets)
+	return tickets, nil
+}
+
+// copyTicket prevents callers from mutating stored tickets without calling Update
+func copyTicket(t *Ticket) *Ticket {
+	c := *t
+	c.Areas = append([]string(nil), t.Areas...)
+	c.History = append([]Transition(nil), t.History...)
+	if t.Metadata != nil {
+		c.Metadata = make(map[string]string, len(t.Metadata))
+		for k, v := range t.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,94 @@
+// Package store persists help request tickets
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a ticket does not exist
+var ErrNotFound = errors.New("ticket not found")
+
+// Status is the state of a ticket in its lifecycle
+type Status string
+
+// StatusNew is the status of a newly created ticket
+const StatusNew Status = "new"
+
+// Ticket is a help request raised by a user
+type Ticket struct {
+	ID          string
+	Status      Status
+	Requester   string // Slack user ID of the person who asked for help
+	Assignee    string // Slack user ID of the person helping
+	Description string
+	Urgency     string
+	Areas       []string
+	NeededBy    string
+	ChannelID   string            // Channel the request was raised from
+	Metadata    map[string]string // Links to other systems, e.g. a JIRA issue key
+	History     []Transition
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Transition records a change of status
+type Transition struct {
+	From  Status
+	To    Status
+	Actor string // Slack user ID of the person who made the change
+	At    time.Time
+}
+
+// Filter restricts the tickets returned by List. Empty fields match every ticket
+type Filter struct {
+	Status    Status
+	Requester string
+	Assignee  string
+}
+
+// Matches reports whether t satisfies the filter
+func (f Filter) Matches(t *Ticket) bool {
+	return (f.Status == "" || f.Status == t.Status) &&
+		(f.Requester == "" || f.Requester == t.Requester) &&
+		(f.Assignee == "" || f.Assignee == t.Assignee)
+}
+
+// TicketStore is an interface for ticket persistence
+type TicketStore interface {
+	// Create assigns a new ID to t and stores it
+	Create(t *Ticket) error
+	// Get returns the ticket with the given ID or ErrNotFound. The prefix of the ID may be
+	// given in any case, e.g. hd-1 for HD-1
+	Get(id string) (*Ticket, error)
+	// Update replaces a stored ticket or returns ErrNotFound
+	Update(t *Ticket) error
+	// Modify atomically loads the ticket with the given ID, passes it to f and stores the
+	// result. Nothing is stored if f returns an error, which is returned. Concurrent
+	// modifications of a ticket are applied one after another so none are lost
+	Modify(id string, f func(*Ticket) error) (*Ticket, error)
+	// List returns the tickets matching f in the order they were created
+	List(f Filter) ([]*Ticket, error)
+}
+
+// normaliseID returns id with its prefix written as the store writes it, so that IDs typed by
+// users match whatever the case of the prefix
+func normaliseID(prefix, id string) string {
+	if len(id) > len(prefix) && id[len(prefix)] == '-' && strings.EqualFold(id[:len(prefix)], prefix) {
+		return prefix + id[len(prefix):]
+	}
+	return id
+}
+
+// sortTickets orders tickets by ID, which are allocated sequentially
+func sortTickets(tickets []*Ticket) {
+	sort.Slice(tickets, func(i, j int) bool {
+		a, b := tickets[i].ID, tickets[j].ID
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+}
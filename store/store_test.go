@@ -0,0 +1,184 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testStore runs the behaviour every TicketStore must provide against s
+func testStore(t *testing.T, s TicketStore) {
+	a := &Ticket{Status: StatusNew, Requester: "U1", Description: "VPN is down", Areas: []string{"network"}}
+	if err := s.Create(a); err != nil {
+		t.Fatalf("Unexpected error creating ticket: %s", err)
+	}
+	if a.ID != "HD-1" || a.CreatedAt.IsZero() {
+		t.Fatalf("Expected an ID and creation time to be assigned. Got %+v", a)
+	}
+	for i := 0; i < 10; i++ {
+		if err := s.Create(&Ticket{Status: StatusNew, Requester: "U2"}); err != nil {
+			t.Fatalf("Unexpected error creating ticket: %s", err)
+		}
+	}
+
+	got, err := s.Get("HD-1")
+	if err != nil {
+		t.Fatalf("Unexpected error getting ticket: %s", err)
+	}
+	if got.Description != "VPN is down" || len(got.Areas) != 1 {
+		t.Fatalf("Unexpected ticket: %+v", got)
+	}
+	if _, err := s.Get("HD-99"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound. Got '%v'", err)
+	}
+	if typed, err := s.Get("hd-1"); err != nil || typed.ID != "HD-1" {
+		t.Fatalf("Expected the prefix to be matched in any case. Got %+v %v", typed, err)
+	}
+
+	got.Status = "in_progress"
+	got.Assignee = "U3"
+	if err := s.Update(got); err != nil {
+		t.Fatalf("Unexpected error updating ticket: %s", err)
+	}
+	if err := s.Update(&Ticket{ID: "HD-99"}); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound. Got '%v'", err)
+	}
+
+	modified, err := s.Modify("hd-2", func(t *Ticket) error {
+		t.Assignee = "U4"
+		return nil
+	})
+	if err != nil || modified.Assignee != "U4" {
+		t.Fatalf("Unexpected result modifying ticket: %+v %v", modified, err)
+	}
+	failed := errors.New("invalid transition")
+	if _, err := s.Modify("HD-2", func(t *Ticket) error {
+		t.Assignee = "U5"
+		return failed
+	}); err != failed {
+		t.Fatalf("Expected the error from f. Got '%v'", err)
+	}
+	if got, _ := s.Get("HD-2"); got.Assignee != "U4" {
+		t.Fatalf("Expected a failed modification not to be stored. Got %s", got.Assignee)
+	}
+	if _, err := s.Modify("HD-99", func(*Ticket) error { return nil }); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound. Got '%v'", err)
+	}
+	testConcurrentModify(t, s, "HD-3")
+
+	tt := []struct {
+		name   string
+		filter Filter
+		ids    []string
+	}{
+		{"By status", Filter{Status: "in_progress"}, []string{"HD-1"}},
+		{"By requester", Filter{Requester: "U1"}, []string{"HD-1"}},
+		{"By assignee", Filter{Assignee: "U3"}, []string{"HD-1"}},
+		{"Combined", Filter{Status: StatusNew, Requester: "U1"}, nil},
+		{"Everything in creation order", Filter{}, []string{"HD-1", "HD-2", "HD-3", "HD-4", "HD-5", "HD-6", "HD-7", "HD-8", "HD-9", "HD-10", "HD-11"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tickets, err := s.List(tc.filter)
+			if err != nil {
+				t.Fatalf("Unexpected error listing tickets: %s", err)
+			}
+			if len(tickets) != len(tc.ids) {
+				t.Fatalf("Expected %d tickets. Got %d", len(tc.ids), len(tickets))
+			}
+			for i, ticket := range tickets {
+				if ticket.ID != tc.ids[i] {
+					t.Fatalf("Expected %s at position %d. Got %s", tc.ids[i], i, ticket.ID)
+				}
+			}
+		})
+	}
+}
+
+// testConcurrentModify checks that no modification is lost when many are made at once
+func testConcurrentModify(t *testing.T, s TicketStore, id string) {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Modify(id, func(t *Ticket) error {
+				t.History = append(t.History, Transition{Actor: fmt.Sprintf("U%d", i)})
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	if got, _ := s.Get(id); len(got.History) != 20 {
+		t.Fatalf("Expected every modification to be kept. Got %d", len(got.History))
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory("HD"))
+}
+
+func TestMemoryCopiesTickets(t *testing.T) {
+	s := NewMemory("HD")
+	ticket := &Ticket{Areas: []string{"network"}}
+	s.Create(ticket)
+	ticket.Areas[0] = "changed"
+	got, _ := s.Get(ticket.ID)
+	if got.Areas[0] != "network" {
+		t.Fatalf("Expected stored tickets to be isolated from callers")
+	}
+}
+
+func TestBolt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tickets.db")
+
+	s, err := NewBolt(path, "HD")
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %s", err)
+	}
+	testStore(t, s)
+	s.Close()
+
+	// Tickets and IDs must survive a restart
+	s, err = NewBolt(path, "HD")
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %s", err)
+	}
+	defer s.Close()
+	if _, err := s.Get("HD-1"); err != nil {
+		t.Fatalf("Expected ticket to be persisted: %s", err)
+	}
+	ticket := &Ticket{}
+	if err := s.Create(ticket); err != nil {
+		t.Fatalf("Unexpected error creating ticket: %s", err)
+	}
+	if ticket.ID != "HD-12" {
+		t.Fatalf("Expected IDs to continue after a restart. Got %s", ticket.ID)
+	}
+}
+
+func TestNormaliseID(t *testing.T) {
+	tt := []struct {
+		prefix, id, want string
+	}{
+		{"HD", "hd-1", "HD-1"},
+		{"HD", "HD-1", "HD-1"},
+		{"help", "HELP-12", "help-12"},
+		{"HD", "hdx-1", "hdx-1"},
+		{"HD", "hd", "hd"},
+	}
+	for _, tc := range tt {
+		if got := normaliseID(tc.prefix, tc.id); got != tc.want {
+			t.Fatalf("Expected %s with prefix %s to be %s. Got %s", tc.id, tc.prefix, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JiraWrapper is an interface for JIRA to enable test double injection
+type JiraWrapper interface {
+	CreateIssue(ctx context.Context, issue JiraIssue) (*JiraIssueRef, error)
+}
+
+// JiraIssue describes an issue to be raised in JIRA
+type JiraIssue struct {
+	Project     string
+	IssueType   string
+	Summary     string
+	Description string
+	Labels      []string
+	// Fields holds any additional fields keyed by JIRA field ID, e.g. customfield_10010
+	Fields map[string]interface{}
+}
+
+// JiraIssueRef identifies an issue which has been created in JIRA
+type JiraIssueRef struct {
+	ID  string
+	Key string
+	URL string
+}
+
+// Jira is a wrapper around the JIRA REST API
+type Jira struct {
+	baseURL  string
+	username string
+	token    string
+	client   *http.Client
+}
+
+// NewJira takes the base URL of a JIRA instance and credentials, verifies the
+// connection and returns an initialised Jira struct
+func NewJira(baseURL, username, token string) (*Jira, error) {
+	j := &Jira{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		token:    token,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+
+	// Check credentials are valid
+	if err := j.do(context.Background(), http.MethodGet, "/rest/api/2/myself", nil, nil); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// CreateIssue raises a new issue in JIRA, giving up if ctx is done first
+func (j *Jira) CreateIssue(ctx context.Context, issue JiraIssue) (*JiraIssueRef, error) {
+	fields := map[string]interface{}{}
+	for k, v := range issue.Fields {
+		fields[k] = v
+	}
+	fields["project"] = map[string]string{"key": issue.Project}
+	fields["issuetype"] = map[string]string{"name": issue.IssueType}
+	fields["summary"] = issue.Summary
+	if issue.Description != "" {
+		fields["description"] = issue.Description
+	}
+	if len(issue.Labels) > 0 {
+		fields["labels"] = issue.Labels
+	}
+
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return nil, fmt.Errorf("error creating issue: %s", err)
+	}
+	return &JiraIssueRef{ID: created.ID, Key: created.Key, URL: j.baseURL + "/browse/" + created.Key}, nil
+}
+
+// do performs an authenticated request against the JIRA API, encoding body and
+// decoding the response into out when they are not nil
+func (j *Jira) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(j.username, j.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return jiraError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jiraError builds an error from the error collection JIRA returns with failed requests
+func jiraError(resp *http.Response) error {
+	var e struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return fmt.Errorf("unexpected status from JIRA: %d", resp.StatusCode)
+	}
+	msgs := e.ErrorMessages
+	for field, msg := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", field, msg))
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("unexpected status from JIRA: %d %s", resp.StatusCode, strings.Join(msgs, ", "))
+}
@@ -0,0 +1,98 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newJiraStub returns a local stand-in for the JIRA REST API which records the fields of created issues
+func newJiraStub(t *testing.T, fields *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "bob" || p != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errorMessages":["You are not authenticated"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/myself":
+			w.Write([]byte(`{"name":"bob"}`))
+		case "/rest/api/2/issue":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Unable to decode issue: %s", err)
+			}
+			*fields = body.Fields
+			if body.Fields["summary"] == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errorMessages":[],"errors":{"summary":"You must specify a summary of the issue."}}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10000","key":"HELP-24","self":"http://jira/rest/api/2/issue/10000"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNewJira(t *testing.T) {
+	var fields map[string]interface{}
+	srv := newJiraStub(t, &fields)
+	defer srv.Close()
+
+	if _, err := NewJira(srv.URL, "bob", "secret"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_, err := NewJira(srv.URL, "bob", "wrong")
+	if err == nil || err.Error() != "unexpected status from JIRA: 401 You are not authenticated" {
+		t.Fatalf("Expected an authentication error. Got '%v'", err)
+	}
+}
+
+func TestCreateIssue(t *testing.T) {
+	var fields map[string]interface{}
+	srv := newJiraStub(t, &fields)
+	defer srv.Close()
+
+	j, err := NewJira(srv.URL+"/", "bob", "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	ref, err := j.CreateIssue(context.Background(), JiraIssue{
+		Project:     "HELP",
+		IssueType:   "Task",
+		Summary:     "My VPN is down",
+		Description: "It stopped working this morning",
+		Labels:      []string{"helpdesk"},
+		Fields:      map[string]interface{}{"customfield_10010": "high"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if ref.Key != "HELP-24" || ref.URL != srv.URL+"/browse/HELP-24" {
+		t.Fatalf("Unexpected issue reference: %+v", ref)
+	}
+
+	expected := map[string]interface{}{
+		"project":           map[string]interface{}{"key": "HELP"},
+		"issuetype":         map[string]interface{}{"name": "Task"},
+		"summary":           "My VPN is down",
+		"description":       "It stopped working this morning",
+		"labels":            []interface{}{"helpdesk"},
+		"customfield_10010": "high",
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("Unexpected issue fields: %v", fields)
+	}
+
+	_, err = j.CreateIssue(context.Background(), JiraIssue{Project: "HELP", IssueType: "Task"})
+	if err == nil || err.Error() != "error creating issue: unexpected status from JIRA: 400 summary: You must specify a summary of the issue." {
+		t.Fatalf("Expected a validation error. Got '%v'", err)
+	}
+}
@@ -0,0 +1,141 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// pagerDutyEventsURL is the endpoint for the PagerDuty Events API v2
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyWrapper is an interface for PagerDuty to enable test double injection
+type PagerDutyWrapper interface {
+	Trigger(ctx context.Context, event PagerDutyEvent) (string, error)
+	Acknowledge(ctx context.Context, dedupKey string) error
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+// PagerDutyEvent describes an incident to be triggered in PagerDuty
+type PagerDutyEvent struct {
+	// DedupKey identifies the incident for later acknowledgement or resolution
+	// PagerDuty generates one if it is left empty
+	DedupKey      string
+	Summary       string
+	Source        string
+	Severity      string // One of critical, error, warning or info
+	CustomDetails map[string]string
+	Links         []PagerDutyLink
+}
+
+// PagerDutyLink is a link attached to a PagerDuty incident
+type PagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+// PagerDuty is a wrapper around the PagerDuty Events API v2
+type PagerDuty struct {
+	routingKey string
+	eventsURL  string
+	client     *http.Client
+}
+
+// NewPagerDuty takes the routing key of a PagerDuty service integration and
+// returns an initialised PagerDuty struct
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		routingKey: routingKey,
+		eventsURL:  pagerDutyEventsURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyRequest struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []PagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key"`
+	Errors   []string `json:"errors"`
+}
+
+// Trigger raises an incident in PagerDuty and returns its dedup key
+func (p *PagerDuty) Trigger(ctx context.Context, event PagerDutyEvent) (string, error) {
+	resp, err := p.send(ctx, pagerDutyRequest{
+		EventAction: "trigger",
+		DedupKey:    event.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       event.Summary,
+			Source:        event.Source,
+			Severity:      event.Severity,
+			CustomDetails: event.CustomDetails,
+		},
+		Links: event.Links,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error triggering incident: %s", err)
+	}
+	return resp.DedupKey, nil
+}
+
+// Acknowledge acknowledges the incident identified by dedupKey
+func (p *PagerDuty) Acknowledge(ctx context.Context, dedupKey string) error {
+	if _, err := p.send(ctx, pagerDutyRequest{EventAction: "acknowledge", DedupKey: dedupKey}); err != nil {
+		return fmt.Errorf("error acknowledging incident: %s", err)
+	}
+	return nil
+}
+
+// Resolve resolves the incident identified by dedupKey
+func (p *PagerDuty) Resolve(ctx context.Context, dedupKey string) error {
+	if _, err := p.send(ctx, pagerDutyRequest{EventAction: "resolve", DedupKey: dedupKey}); err != nil {
+		return fmt.Errorf("error resolving incident: %s", err)
+	}
+	return nil
+}
+
+// send posts an event to PagerDuty, giving up if ctx is done first
+func (p *PagerDuty) send(ctx context.Context, r pagerDutyRequest) (*pagerDutyResponse, error) {
+	r.RoutingKey = p.routingKey
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.eventsURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var pr pagerDutyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("unexpected status from PagerDuty: %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("unexpected status from PagerDuty: %d %s %v", resp.StatusCode, pr.Message, pr.Errors)
+	}
+	return &pr, nil
+}
@@ -0,0 +1,86 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newPagerDutyStub returns a local stand-in for the PagerDuty Events API which records received events
+func newPagerDutyStub(t *testing.T, events *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Unable to decode event: %s", err)
+		}
+		*events = append(*events, e)
+		if e["routing_key"] != "ROUTING" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["Invalid routing key"]}`))
+			return
+		}
+		dedupKey, _ := e["dedup_key"].(string)
+		if dedupKey == "" {
+			dedupKey = "generated"
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Event processed", "dedup_key": dedupKey})
+	}))
+}
+
+func TestPagerDutyLifecycle(t *testing.T) {
+	var events []map[string]interface{}
+	srv := newPagerDutyStub(t, &events)
+	defer srv.Close()
+
+	p := NewPagerDuty("ROUTING")
+	p.eventsURL = srv.URL
+	key, err := p.Trigger(context.Background(), PagerDutyEvent{
+		DedupKey: "slack-C1AB2C3DE-1503435956.000247",
+		Summary:  "VPN is down",
+		Source:   "go-helpdesk",
+		Severity: "critical",
+		Links:    []PagerDutyLink{{Href: "https://slack.com/archives/C1AB2C3DE/p1503435956000247", Text: "Slack thread"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if key != "slack-C1AB2C3DE-1503435956.000247" {
+		t.Fatalf("Unexpected dedup key: %s", key)
+	}
+	if err := p.Acknowledge(context.Background(), key); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := p.Resolve(context.Background(), key); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	actions := []string{"trigger", "acknowledge", "resolve"}
+	for i, e := range events {
+		if e["event_action"] != actions[i] || e["dedup_key"] != key {
+			t.Fatalf("Unexpected event %d: %v", i, e)
+		}
+	}
+	payload := events[0]["payload"].(map[string]interface{})
+	if payload["summary"] != "VPN is down" || payload["severity"] != "critical" {
+		t.Fatalf("Unexpected payload: %v", payload)
+	}
+	if _, ok := events[1]["payload"]; ok {
+		t.Fatalf("Acknowledge events should not carry a payload")
+	}
+}
+
+func TestPagerDutyErrors(t *testing.T) {
+	var events []map[string]interface{}
+	srv := newPagerDutyStub(t, &events)
+	defer srv.Close()
+
+	p := NewPagerDuty("WRONG")
+	p.eventsURL = srv.URL
+	err := p.Acknowledge(context.Background(), "abc")
+	if err == nil || err.Error() != "error acknowledging incident: unexpected status from PagerDuty: 400 Event object is invalid [Invalid routing key]" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -1,8 +1,7 @@
 package wrapper
 
 import (
-	//"github.com/BeepBoopHQ/go-slackbot"
-	"github.com/nlopes/slack"
+	"github.com/slack-go/slack"
 
 	"fmt"
 )
@@ -10,7 +9,29 @@ import (
 // SlackWrapper is a interface for Slack to enable test double injection
 type SlackWrapper interface {
 	OpenDialog(triggerID string, dialog slack.Dialog) error
-	//SendMessage(message, channel string)
+	OpenView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	UpdateView(viewID, hash string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	PushView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	PublishHomeView(userID string, view slack.HomeTabViewRequest) (*slack.ViewResponse, error)
+	PostMessage(channelID string, msg Message) (MessageRef, error)
+	PostEphemeral(channelID, userID string, msg Message) error
+	PostThreadReply(parent MessageRef, msg Message) (MessageRef, error)
+	UpdateMessage(ref MessageRef, msg Message) (MessageRef, error)
+	DeleteMessage(ref MessageRef) error
+}
+
+// Message is the content of a Slack message. When Blocks are provided Text is
+// used as the fallback for notifications
+type Message struct {
+	Text   string
+	Blocks []slack.Block
+}
+
+// MessageRef identifies a message posted to Slack so that it can later be
+// updated, deleted or replied to
+type MessageRef struct {
+	ChannelID string
+	Timestamp string
 }
 
 // Slack is a wrapper around the Slack App and RTM APIs
@@ -46,32 +67,93 @@ func (s *Slack) OpenDialog(triggerID string, dialog slack.Dialog) error {
 	}
 	return err
 }
-//
-//// SendMessage posts a message to Slack that is visible to everyone in the channel
-//func (c slack.Client) SendMessage(channelID, message string, params slack.PostMessageParameters) {
-//	s.sendMessage(channelID, message, params, []slack.MsgOption{})
-//}
-//
-//func (s *Slack) SendMessageWithAttachments(channelID, message string, params slack.PostMessageParameters, attachments []slack.Attachment) {
-//	var opts []slack.MsgOption
-//	for _, attachment := range attachments {
-//		opts = append(opts, slack.MsgOptionAttachments(attachment))
-//	}
-//	s.sendMessage(channelID, message, params, opts)
-//}
-//
-//func (s *Slack) sendMessage(channelID, message string, params slack.PostMessageParameters, opts []slack.MsgOption) {
-//	opts = append(opts, slack.MsgOptionText(message, true))
-//
-//	if params.AsUser {
-//		opts = append(opts, slack.MsgOptionAsUser(true))
-//		opts = append(opts, slack.MsgOptionUser(params.Username))
-//	}
-//
-//	channelId := fmt.Sprintf("#%s", channelID)
-//	_, _, err := s.Bot.PostMessage(channelId, opts...)
-//	if err != nil {
-//		fmt.Printf("%s\n", err)
-//		return
-//	}
-//}
\ No newline at end of file
+
+// OpenView opens a modal inside Slack
+func (s *Slack) OpenView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	resp, err := s.App.OpenView(triggerID, view)
+	if err != nil {
+		return nil, fmt.Errorf("error opening view: %s", err)
+	}
+	return resp, nil
+}
+
+// UpdateView replaces the content of an open modal. If hash is provided the update
+// only succeeds if the modal has not changed since the hash was issued
+func (s *Slack) UpdateView(viewID, hash string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	resp, err := s.App.UpdateView(view, "", hash, viewID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating view: %s", err)
+	}
+	return resp, nil
+}
+
+// PushView pushes a new modal onto the stack of an open modal
+func (s *Slack) PushView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	resp, err := s.App.PushView(triggerID, view)
+	if err != nil {
+		return nil, fmt.Errorf("error pushing view: %s", err)
+	}
+	return resp, nil
+}
+
+// PublishHomeView publishes the App Home tab for a user
+func (s *Slack) PublishHomeView(userID string, view slack.HomeTabViewRequest) (*slack.ViewResponse, error) {
+	resp, err := s.Bot.PublishView(userID, view, "")
+	if err != nil {
+		return nil, fmt.Errorf("error publishing home view: %s", err)
+	}
+	return resp, nil
+}
+
+// PostMessage posts a message to Slack that is visible to everyone in the channel
+func (s *Slack) PostMessage(channelID string, msg Message) (MessageRef, error) {
+	ch, ts, err := s.Bot.PostMessage(channelID, msg.options()...)
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("error posting message: %s", err)
+	}
+	return MessageRef{ChannelID: ch, Timestamp: ts}, nil
+}
+
+// PostEphemeral posts a message to Slack that is only visible to userID
+// Ephemeral messages can not be updated or deleted so no reference is returned
+func (s *Slack) PostEphemeral(channelID, userID string, msg Message) error {
+	if _, err := s.Bot.PostEphemeral(channelID, userID, msg.options()...); err != nil {
+		return fmt.Errorf("error posting ephemeral message: %s", err)
+	}
+	return nil
+}
+
+// PostThreadReply posts a message as a threaded reply to parent
+func (s *Slack) PostThreadReply(parent MessageRef, msg Message) (MessageRef, error) {
+	opts := append(msg.options(), slack.MsgOptionTS(parent.Timestamp))
+	ch, ts, err := s.Bot.PostMessage(parent.ChannelID, opts...)
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("error posting thread reply: %s", err)
+	}
+	return MessageRef{ChannelID: ch, Timestamp: ts}, nil
+}
+
+// UpdateMessage replaces the content of a previously posted message
+func (s *Slack) UpdateMessage(ref MessageRef, msg Message) (MessageRef, error) {
+	ch, ts, _, err := s.Bot.UpdateMessage(ref.ChannelID, ref.Timestamp, msg.options()...)
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("error updating message: %s", err)
+	}
+	return MessageRef{ChannelID: ch, Timestamp: ts}, nil
+}
+
+// DeleteMessage deletes a previously posted message
+func (s *Slack) DeleteMessage(ref MessageRef) error {
+	if _, _, err := s.Bot.DeleteMessage(ref.ChannelID, ref.Timestamp); err != nil {
+		return fmt.Errorf("error deleting message: %s", err)
+	}
+	return nil
+}
+
+func (m Message) options() []slack.MsgOption {
+	opts := []slack.MsgOption{slack.MsgOptionText(m.Text, false)}
+	if len(m.Blocks) > 0 {
+		opts = append(opts, slack.MsgOptionBlocks(m.Blocks...))
+	}
+	return opts
+}
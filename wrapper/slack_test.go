@@ -1,6 +1,15 @@
 package wrapper
 
-import "testing"
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
 
 func TestInit(t *testing.T) {
 	_, err := New("", "")
@@ -8,3 +17,69 @@ func TestInit(t *testing.T) {
 		t.Errorf("Invalid slack connections did not return an error")
 	}
 }
+
+// newTestSlack returns a Slack wrapper whose clients talk to a local stand-in for the Slack API
+// Each API call is recorded in calls, keyed by method name
+func newTestSlack(t *testing.T, calls map[string]url.Values) (*Slack, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Unable to parse API request: %s", err)
+			return
+		}
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		calls[method] = r.Form
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"channel":"%s","ts":"1503435956.000247","message_ts":"1503435956.000247"}`, r.Form.Get("channel"))
+	}))
+	client := slack.New("TOKEN", slack.OptionAPIURL(srv.URL+"/"))
+	return &Slack{App: client, Bot: client}, srv.Close
+}
+
+func TestMessaging(t *testing.T) {
+	calls := map[string]url.Values{}
+	s, done := newTestSlack(t, calls)
+	defer done()
+
+	ref, err := s.PostMessage("C1AB2C3DE", Message{Text: "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error posting message: %s", err)
+	}
+	if ref.ChannelID != "C1AB2C3DE" || ref.Timestamp != "1503435956.000247" {
+		t.Fatalf("Unexpected message reference: %+v", ref)
+	}
+	if calls["chat.postMessage"].Get("text") != "hello" {
+		t.Fatalf("Unexpected message text: %s", calls["chat.postMessage"].Get("text"))
+	}
+
+	blocks := []slack.Block{slack.NewDividerBlock()}
+	if _, err := s.PostThreadReply(ref, Message{Text: "reply", Blocks: blocks}); err != nil {
+		t.Fatalf("Unexpected error posting reply: %s", err)
+	}
+	if calls["chat.postMessage"].Get("thread_ts") != ref.Timestamp {
+		t.Fatalf("Expected reply to be threaded under %s", ref.Timestamp)
+	}
+	if calls["chat.postMessage"].Get("blocks") == "" {
+		t.Fatal("Expected blocks to be sent with the reply")
+	}
+
+	if err := s.PostEphemeral("C1AB2C3DE", "W12A3BCDEF", Message{Text: "psst"}); err != nil {
+		t.Fatalf("Unexpected error posting ephemeral message: %s", err)
+	}
+	if calls["chat.postEphemeral"].Get("user") != "W12A3BCDEF" {
+		t.Fatalf("Unexpected ephemeral user: %s", calls["chat.postEphemeral"].Get("user"))
+	}
+
+	if _, err := s.UpdateMessage(ref, Message{Text: "updated"}); err != nil {
+		t.Fatalf("Unexpected error updating message: %s", err)
+	}
+	if calls["chat.update"].Get("ts") != ref.Timestamp {
+		t.Fatalf("Unexpected timestamp for update: %s", calls["chat.update"].Get("ts"))
+	}
+
+	if err := s.DeleteMessage(ref); err != nil {
+		t.Fatalf("Unexpected error deleting message: %s", err)
+	}
+	if calls["chat.delete"].Get("ts") != ref.Timestamp {
+		t.Fatalf("Unexpected timestamp for delete: %s", calls["chat.delete"].Get("ts"))
+	}
+}